@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditRotationTimeFormat is the timestamp in rotated audit log names. It is fixed width
+// so names sort by time, and has nanoseconds so rotations in the same second do not collide.
+const auditRotationTimeFormat = "20060102T150405.000000000Z"
+
+// rotatedLogExt is the extension of rotated audit logs, whatever the live log's extension
+const rotatedLogExt = ".log"
+
+// AuditRecord is one line of the audit log, written after each completed turn
+type AuditRecord struct {
+	Time           time.Time `json:"time"`
+	ConversationID string    `json:"conversation_id"`
+	Model          string    `json:"model"`
+	Question       string    `json:"question"`
+	Response       string    `json:"response"`
+	// Messages and MessageHashes are recorded with -message-content-hash, see audit-verify
+	Messages      []AuditMessage `json:"messages,omitempty"`
+	MessageHashes []MessageHash  `json:"message_hashes,omitempty"`
+}
+
+// AuditLogger appends AuditRecords to a file as JSON lines
+type AuditLogger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// auditLog is opened from -audit-log
+var auditLog *AuditLogger
+
+// openAuditLog opens path for appending, creating it if needed
+func openAuditLog(path string) (*AuditLogger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLogger{file: file}, nil
+}
+
+// Write appends record as a single JSON line
+func (l *AuditLogger) Write(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the underlying file
+func (l *AuditLogger) Close() error {
+	return l.file.Close()
+}
+
+// rotatedLogName returns <base>.<timestamp>.log for path, where base is path without its extension
+func rotatedLogName(path string, t time.Time) string {
+	return rotatedLogBase(path) + "." + t.UTC().Format(auditRotationTimeFormat) + rotatedLogExt
+}
+
+func rotatedLogBase(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// rotateLogFile renames an existing log at path to its timestamped name; a missing file is not rotated
+func rotateLogFile(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Never replace an earlier archive, even with a coarse clock
+	now := time.Now()
+	name := rotatedLogName(path, now)
+	for {
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return err
+		}
+		now = now.Add(time.Nanosecond)
+		name = rotatedLogName(path, now)
+	}
+	return os.Rename(path, name)
+}
+
+// rotatedLogFiles returns the rotated copies of path, oldest first
+func rotatedLogFiles(path string) ([]string, error) {
+	base := rotatedLogBase(path)
+	matches, err := filepath.Glob(base + ".*" + rotatedLogExt)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, base+"."), rotatedLogExt)
+		if _, err := time.Parse(auditRotationTimeFormat, stamp); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+// pruneRotatedLogs deletes all but the maxFiles most recent rotated copies of path;
+// maxFiles <= 0 keeps them all
+func pruneRotatedLogs(path string, maxFiles int) error {
+	if maxFiles <= 0 {
+		return nil
+	}
+	rotated, err := rotatedLogFiles(path)
+	if err != nil {
+		return err
+	}
+	for len(rotated) > maxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			return fmt.Errorf("removing rotated log %s: %w", rotated[0], err)
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// recordAudit writes an audit record for a completed turn when -audit-log is set
+func recordAudit(session *Session, question, response string) {
+	if auditLog == nil {
+		return
+	}
+	record := AuditRecord{
+		Time:           time.Now().UTC(),
+		ConversationID: session.Metadata.ID,
+		Model:          *modelName,
+		Question:       question,
+		Response:       response,
+	}
+	if *messageContentHash {
+		record.Messages = auditMessages(session.Messages)
+		record.MessageHashes = hashMessages(session.Messages)
+	}
+	if err := auditLog.Write(record); err != nil {
+		log.Printf("Warning: failed to write audit record: %v", err)
+	}
+}
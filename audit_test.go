@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRotatedLogName(t *testing.T) {
+	at := time.Date(2025, 4, 2, 9, 30, 15, 123456789, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"audit.jsonl", "audit.20250402T073015.123456789Z.log"},
+		{"logs/audit.log", "logs/audit.20250402T073015.123456789Z.log"},
+		{"audit", "audit.20250402T073015.123456789Z.log"},
+	}
+	for _, tt := range tests {
+		if got := rotatedLogName(tt.path, at); got != tt.want {
+			t.Errorf("rotatedLogName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRotateLogFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	if err := rotateLogFile(path); err != nil {
+		t.Fatalf("rotating a missing log: %v", err)
+	}
+
+	// Two rotations within the same second keep both archives
+	for _, content := range []string{"first run\n", "second run\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := rotateLogFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("live log still exists after rotation: %v", err)
+	}
+	rotated, err := rotatedLogFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("got %d rotated logs, want 2: %v", len(rotated), rotated)
+	}
+	name := regexp.MustCompile(`^audit\.\d{8}T\d{6}\.\d{9}Z\.log$`)
+	for i, want := range []string{"first run\n", "second run\n"} {
+		if !name.MatchString(filepath.Base(rotated[i])) {
+			t.Errorf("rotated log name %s does not match %s", filepath.Base(rotated[i]), name)
+		}
+		if data, _ := os.ReadFile(rotated[i]); string(data) != want {
+			t.Errorf("rotated log %d holds %q, want %q", i, data, want)
+		}
+	}
+}
+
+func TestPruneRotatedLogs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	start := time.Date(2025, 4, 2, 9, 0, 0, 0, time.UTC)
+	var names []string
+	for i := 0; i < 5; i++ {
+		name := rotatedLogName(path, start.Add(time.Duration(i)*time.Hour))
+		names = append(names, name)
+		if err := os.WriteFile(name, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unrelated := filepath.Join(dir, "audit.notes.log")
+	if err := os.WriteFile(unrelated, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneRotatedLogs(path, 2); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range names {
+		_, err := os.Stat(name)
+		if kept := err == nil; kept != (i >= 3) {
+			t.Errorf("%s kept = %v, want %v", filepath.Base(name), kept, i >= 3)
+		}
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("pruning removed an unrelated file: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	openai "github.com/openai/openai-go"
+)
+
+// AuditMessage is the role and text of a message as recorded in the audit log
+type AuditMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// MessageHash is the SHA-256 of one message's role and content
+type MessageHash struct {
+	MessageIndex int    `json:"message_index"`
+	Hash         string `json:"hash"`
+}
+
+// hashMessageContent returns the hex sha256(role + content)
+func hashMessageContent(role, content string) string {
+	sum := sha256.Sum256([]byte(role + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditMessages converts msgs to the role and text recorded in the audit log
+func auditMessages(msgs []openai.ChatCompletionMessageParamUnion) []AuditMessage {
+	recorded := make([]AuditMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		recorded = append(recorded, AuditMessage{Role: messageRole(msg), Content: messageText(msg)})
+	}
+	return recorded
+}
+
+// hashMessages hashes each message in msgs, in order
+func hashMessages(msgs []openai.ChatCompletionMessageParamUnion) []MessageHash {
+	return hashAuditMessages(auditMessages(msgs))
+}
+
+func hashAuditMessages(msgs []AuditMessage) []MessageHash {
+	hashes := make([]MessageHash, 0, len(msgs))
+	for i, msg := range msgs {
+		hashes = append(hashes, MessageHash{MessageIndex: i, Hash: hashMessageContent(msg.Role, msg.Content)})
+	}
+	return hashes
+}
+
+// verifyAuditRecord re-hashes the recorded messages and returns a description of each
+// mismatch with the recorded hashes
+func verifyAuditRecord(record AuditRecord) []string {
+	var problems []string
+	if len(record.Messages) != len(record.MessageHashes) {
+		problems = append(problems, fmt.Sprintf("%d messages but %d hashes", len(record.Messages), len(record.MessageHashes)))
+	}
+	for i, h := range hashAuditMessages(record.Messages) {
+		if i >= len(record.MessageHashes) {
+			break
+		}
+		recorded := record.MessageHashes[i]
+		if recorded.MessageIndex != h.MessageIndex || recorded.Hash != h.Hash {
+			problems = append(problems, fmt.Sprintf("message %d does not match its hash", i))
+		}
+	}
+	return problems
+}
+
+// runAuditVerify implements the audit-verify subcommand
+func runAuditVerify(args []string) error {
+	fs := flag.NewFlagSet("audit-verify", flag.ExitOnError)
+	path := fs.String("audit-log", "", "Audit log file to verify")
+	fs.Parse(args)
+
+	if *path == "" {
+		return errors.New("audit-verify: -audit-log is required")
+	}
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line, verified, tampered := 0, 0, 0
+	for scanner.Scan() {
+		line++
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("audit-verify: line %d: %w", line, err)
+		}
+		if len(record.MessageHashes) == 0 {
+			continue
+		}
+		problems := verifyAuditRecord(record)
+		for _, p := range problems {
+			fmt.Printf("line %d (%s): %s\n", line, record.ConversationID, p)
+		}
+		if len(problems) > 0 {
+			tampered++
+		} else {
+			verified++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("%d records verified, %d tampered\n", verified, tampered)
+	if tampered > 0 {
+		return fmt.Errorf("audit-verify: %d tampered records in %s", tampered, *path)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestHashMessageContent(t *testing.T) {
+	want := "0fb9ae9ca07f075d9181069dec6931a7ad786c09d6959df0a66fa5f342b6a360"
+	if got := hashMessageContent("user", "Weather in Paris?"); got != want {
+		t.Errorf("hashMessageContent = %s, want %s", got, want)
+	}
+}
+
+// writeHashedAuditLog writes one audit record with message hashes for msgs to a new log
+func writeHashedAuditLog(t *testing.T, msgs []openai.ChatCompletionMessageParamUnion) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger, err := openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := AuditRecord{ConversationID: "conv-1", Messages: auditMessages(msgs), MessageHashes: hashMessages(msgs)}
+	if err := logger.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readAuditRecords decodes every record in the audit log at path
+func readAuditRecords(t *testing.T, path string) []AuditRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+var testAuditConversation = []openai.ChatCompletionMessageParamUnion{
+	openai.SystemMessage("You are a helpful assistant."),
+	openai.UserMessage("Weather in Paris?"),
+	openai.AssistantMessage("Sunny, 25°C."),
+}
+
+func TestAuditHashRoundTrip(t *testing.T) {
+	hashes := hashMessages(testAuditConversation)
+	path := writeHashedAuditLog(t, testAuditConversation)
+
+	records := readAuditRecords(t, path)
+	if len(records) != 1 {
+		t.Fatalf("log has %d records, want 1", len(records))
+	}
+	if !reflect.DeepEqual(records[0].MessageHashes, hashes) {
+		t.Errorf("reloaded hashes = %+v, want %+v", records[0].MessageHashes, hashes)
+	}
+	if rehashed := hashAuditMessages(records[0].Messages); !reflect.DeepEqual(rehashed, hashes) {
+		t.Errorf("rehashed = %+v, want %+v", rehashed, hashes)
+	}
+	if problems := verifyAuditRecord(records[0]); len(problems) > 0 {
+		t.Errorf("untouched record failed verification: %q", problems)
+	}
+	if err := runAuditVerify([]string{"-audit-log", path}); err != nil {
+		t.Errorf("audit-verify of an untouched log: %v", err)
+	}
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	path := writeHashedAuditLog(t, testAuditConversation)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), "Sunny, 25°C.", "Snowing, -5°C.", 1)
+	if tampered == string(data) {
+		t.Fatal("assistant content not found in the log")
+	}
+	if err := os.WriteFile(path, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readAuditRecords(t, path)
+	if problems := verifyAuditRecord(records[0]); len(problems) != 1 || problems[0] != "message 2 does not match its hash" {
+		t.Errorf("problems = %q, want message 2 reported", problems)
+	}
+	if err := runAuditVerify([]string{"-audit-log", path}); err == nil {
+		t.Error("audit-verify accepted a tampered log")
+	}
+}
+
+func TestVerifyAuditRecordMissingHash(t *testing.T) {
+	record := AuditRecord{Messages: auditMessages(testAuditConversation), MessageHashes: hashMessages(testAuditConversation)[:2]}
+	if problems := verifyAuditRecord(record); len(problems) != 1 {
+		t.Errorf("problems = %q, want the count mismatch only", problems)
+	}
+}
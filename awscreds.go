@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// applyAWSCredentialsFile fills the AWS key flags from -aws-credentials-file unless
+// -aws-access-key-id was given
+func applyAWSCredentialsFile() error {
+	if *awsCredentialsFile == "" || *awsAccessKeyID != "" {
+		return nil
+	}
+	accessKeyID, secretKey, sessionToken, err := parseAWSCredentialsFile(*awsCredentialsFile, *awsProfile)
+	if err != nil {
+		return err
+	}
+	*awsAccessKeyID, *awsSecretKey = accessKeyID, secretKey
+	if *awsSessionToken == "" {
+		*awsSessionToken = sessionToken
+	}
+	return nil
+}
+
+// parseAWSCredentialsFile reads the keys for profile from a shared credentials file.
+// Both the ~/.aws/credentials layout ([name]) and the ~/.aws/config layout
+// ([profile name], with [default] left unprefixed) are accepted.
+func parseAWSCredentialsFile(path, profile string) (accessKeyID, secretKey, sessionToken string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", "", err
+	}
+	defer file.Close()
+
+	found := false
+	inProfile := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			section = strings.TrimSpace(strings.TrimPrefix(section, "profile "))
+			inProfile = section == profile
+			found = found || inProfile
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "aws_access_key_id":
+			accessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			secretKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			sessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", "", err
+	}
+	if !found {
+		return "", "", "", fmt.Errorf("profile %q not found in %s", profile, path)
+	}
+	if accessKeyID == "" || secretKey == "" {
+		return "", "", "", fmt.Errorf("profile %q in %s is missing aws_access_key_id or aws_secret_access_key", profile, path)
+	}
+	return accessKeyID, secretKey, sessionToken, nil
+}
+
+// defaultAWSProfile returns AWS_PROFILE when set, otherwise "default"
+func defaultAWSProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
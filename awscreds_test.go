@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseAWSCredentialsFile(t *testing.T) {
+	credentials := writeTestFile(t, "credentials", `[default]
+aws_access_key_id = DEFAULTKEY
+aws_secret_access_key = defaultsecret
+
+[demo]
+aws_access_key_id = AKIADEMO
+aws_secret_access_key = demosecret
+aws_session_token = demotoken
+`)
+	config := writeTestFile(t, "config", `# shared config
+[default]
+region = eu-central-1
+
+[profile demo]
+region = us-east-1
+aws_access_key_id=AKIACONFIG
+aws_secret_access_key=configsecret
+`)
+
+	tests := []struct {
+		path                     string
+		wantKey, wantSecret, tok string
+	}{
+		{credentials, "AKIADEMO", "demosecret", "demotoken"},
+		{config, "AKIACONFIG", "configsecret", ""},
+	}
+	for _, tt := range tests {
+		key, secret, token, err := parseAWSCredentialsFile(tt.path, "demo")
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(tt.path), err)
+		}
+		if key != tt.wantKey || secret != tt.wantSecret || token != tt.tok {
+			t.Errorf("%s: got (%q, %q, %q)", filepath.Base(tt.path), key, secret, token)
+		}
+	}
+}
+
+func TestParseAWSCredentialsFileMissingProfile(t *testing.T) {
+	path := writeTestFile(t, "credentials", "[default]\naws_access_key_id = A\naws_secret_access_key = B\n")
+	_, _, _, err := parseAWSCredentialsFile(path, "missing")
+	if err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+		t.Errorf("err = %v, want a profile not found error", err)
+	}
+}
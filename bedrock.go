@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// bedrockService is the SigV4 service name for Bedrock
+const bedrockService = "bedrock"
+
+// RegionRotator cycles through Bedrock regions, safe for concurrent use
+type RegionRotator struct {
+	Regions []string
+	current int32
+}
+
+// Current returns the region in use
+func (r *RegionRotator) Current() string {
+	return r.Regions[int(atomic.LoadInt32(&r.current))%len(r.Regions)]
+}
+
+// Next advances to the following region and returns it
+func (r *RegionRotator) Next() string {
+	return r.Regions[int(atomic.AddInt32(&r.current, 1))%len(r.Regions)]
+}
+
+// defaultAWSRegion returns AWS_REGION when set, otherwise eu-central-1
+func defaultAWSRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return "eu-central-1"
+}
+
+// bedrockEndpoint fills the region into the endpoint template
+func bedrockEndpoint(template, region string) string {
+	return strings.ReplaceAll(template, "{region}", region)
+}
+
+// BedrockRegionTransport signs requests with SigV4 for the current region and, when the
+// region cannot be reached or does not know the model, fails over to the next one
+type BedrockRegionTransport struct {
+	Base             http.RoundTripper
+	Rotator          *RegionRotator
+	EndpointTemplate string
+	Credentials      awsCredentials
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *BedrockRegionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	region := t.Rotator.Current()
+	for attempt := 1; ; attempt++ {
+		regional, err := t.regionalRequest(req, body, region)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := base.RoundTrip(regional)
+
+		reason := ""
+		switch {
+		case isDialError(err):
+			reason = "connection failed"
+		case err == nil && resp.StatusCode == http.StatusNotFound:
+			reason = "model not found"
+		}
+		if reason == "" || attempt >= len(t.Rotator.Regions) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		next := t.Rotator.Next()
+		log.Printf("Warning: Bedrock region %s failed (%s), switching to %s", region, reason, next)
+		region = next
+	}
+}
+
+// isDialError reports whether err means no connection to the region could be made, such
+// as a refused connection or an unresolvable endpoint. Matching on the dial operation
+// rather than the errno works the same on every platform.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// regionalRequest points a copy of req at the endpoint for region and signs it
+func (t *BedrockRegionTransport) regionalRequest(req *http.Request, body []byte, region string) (*http.Request, error) {
+	endpoint, err := url.Parse(bedrockEndpoint(t.EndpointTemplate, region))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Bedrock endpoint for region %s: %w", region, err)
+	}
+	regional := req.Clone(req.Context())
+	regional.URL.Scheme = endpoint.Scheme
+	regional.URL.Host = endpoint.Host
+	regional.Host = endpoint.Host
+	regional.Body = io.NopCloser(bytes.NewReader(body))
+	regional.ContentLength = int64(len(body))
+	regional.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	signV4(regional, body, t.Credentials, region, bedrockService, time.Now())
+	return regional, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// regionServer serves a fixed status and body, counting the requests it receives
+func regionServer(t *testing.T, status int, body string, hits *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			http.Error(w, "unsigned request", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// refusingServerURL returns the URL of a server that has been shut down, so connections are refused
+func refusingServerURL() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestBedrockRegionFailover(t *testing.T) {
+	tests := []struct {
+		name string
+		// statuses are the regional responses, 0 for a region refusing connections
+		statuses   []int
+		wantRegion int
+	}{
+		{"first refuses", []int{0, http.StatusOK, http.StatusOK}, 1},
+		{"first refuses, second has no model", []int{0, http.StatusNotFound, http.StatusOK}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := make([]int32, len(tt.statuses))
+			var regions []string
+			for i, status := range tt.statuses {
+				if status == 0 {
+					regions = append(regions, refusingServerURL())
+					continue
+				}
+				regions = append(regions, regionServer(t, status, fmt.Sprintf("region %d", i), &hits[i]).URL)
+			}
+			rotator := &RegionRotator{Regions: regions}
+			client := &http.Client{Transport: &BedrockRegionTransport{
+				Rotator:          rotator,
+				EndpointTemplate: "{region}",
+				Credentials:      awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretKey: "secret"},
+			}}
+
+			resp, err := client.Post("http://bedrock.invalid/model/invoke", "application/json", strings.NewReader(`{"prompt":"Weather?"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if want := fmt.Sprintf("region %d", tt.wantRegion); resp.StatusCode != http.StatusOK || string(body) != want {
+				t.Errorf("got %d %q, want 200 %q", resp.StatusCode, body, want)
+			}
+			if rotator.Current() != regions[tt.wantRegion] {
+				t.Errorf("rotator is on %s, want %s", rotator.Current(), regions[tt.wantRegion])
+			}
+			for i := tt.wantRegion + 1; i < len(hits); i++ {
+				if hits[i] != 0 {
+					t.Errorf("region %d was tried after region %d answered", i, tt.wantRegion)
+				}
+			}
+		})
+	}
+}
+
+func TestBedrockRegionFailoverGivesUp(t *testing.T) {
+	rotator := &RegionRotator{Regions: []string{refusingServerURL(), refusingServerURL()}}
+	client := &http.Client{Transport: &BedrockRegionTransport{Rotator: rotator, EndpointTemplate: "{region}"}}
+	if _, err := client.Get("http://bedrock.invalid/"); err == nil {
+		t.Error("request succeeded with every region refusing connections")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadCABundle returns a pool with the certificates in the PEM file at path, added to
+// the system pool unless override is set. Every block must be a valid CERTIFICATE.
+func loadCABundle(path string, override bool) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !override {
+		if pool, err = x509.SystemCertPool(); err != nil {
+			return nil, fmt.Errorf("loading system certificates: %w", err)
+		}
+	}
+
+	var failed []string
+	added := 0
+	for n := 1; ; n++ {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			failed = append(failed, fmt.Sprintf("block %d: type %q is not CERTIFICATE", n, block.Type))
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("block %d: %v", n, err))
+			continue
+		}
+		pool.AddCert(cert)
+		added++
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("invalid CA bundle %s: %s", path, strings.Join(failed, "; "))
+	}
+	if added == 0 {
+		return nil, errors.New("CA bundle " + path + " has no certificates")
+	}
+	return pool, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCA returns a self-signed CA certificate and its key
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Gateway CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// newCASignedServer starts a TLS server for 127.0.0.1 whose certificate is signed by ca
+func newCASignedServer(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "gateway"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGatewayCABundle(t *testing.T) {
+	ca, caKey := newTestCA(t)
+	srv := newCASignedServer(t, ca, caKey)
+	bundle := writeTestFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})))
+	setFlag(t, useAIGateway, true)
+
+	tests := []struct {
+		bundle   string
+		override bool
+		wantErr  bool
+	}{
+		{"", false, true},
+		{bundle, false, false},
+		{bundle, true, false},
+	}
+	for _, tt := range tests {
+		setFlag(t, gatewayCABundle, tt.bundle)
+		setFlag(t, gatewayCABundleOverride, tt.override)
+		client, err := buildHTTPClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if tt.wantErr != (err != nil) {
+			t.Errorf("bundle %q override %v: err = %v, want error %v", tt.bundle, tt.override, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadCABundleRejectsMalformedPEM(t *testing.T) {
+	ca, _ := newTestCA(t)
+	valid := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))
+	tests := map[string]string{
+		"empty.pem":       "",
+		"not-pem.pem":     "not a certificate\n",
+		"private-key.pem": valid + string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1, 2, 3}})),
+		"bad-der.pem":     string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")})),
+	}
+	for name, content := range tests {
+		if _, err := loadCABundle(writeTestFile(t, name, content), true); err == nil {
+			t.Errorf("loadCABundle(%s) succeeded", name)
+		}
+	}
+	if _, err := loadCABundle(filepath.Join(t.TempDir(), "missing.pem"), true); !os.IsNotExist(err) {
+		t.Errorf("missing bundle: err = %v", err)
+	}
+	if _, err := loadCABundle(writeTestFile(t, "valid.pem", valid), true); err != nil {
+		t.Errorf("valid bundle: %v", err)
+	}
+}
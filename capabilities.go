@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// errCapabilitiesUnavailable means the gateway does not report model capabilities
+var errCapabilitiesUnavailable = errors.New("model capabilities not reported by gateway")
+
+// checkModelSupportsTools asks the gateway whether model lists "tools" among its capabilities
+func checkModelSupportsTools(ctx context.Context, client *http.Client, baseURL, model string) (bool, error) {
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/v1/models/" + url.PathEscape(model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, errCapabilitiesUnavailable
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("GET %s: unexpected status %s", endpoint, resp.Status)
+	}
+
+	var body struct {
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("decoding model %s: %w", model, err)
+	}
+	if body.Capabilities == nil {
+		return false, errCapabilitiesUnavailable
+	}
+	for _, c := range body.Capabilities {
+		if c == "tools" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
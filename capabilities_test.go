@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckModelSupportsTools(t *testing.T) {
+	var requestedPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.EscapedPath()
+		switch r.URL.Path {
+		case "/v1/models/titan-text":
+			w.Write([]byte(`{"id":"titan-text","capabilities":["chat","streaming"]}`))
+		case "/v1/models/claude:0":
+			w.Write([]byte(`{"id":"claude:0","capabilities":["chat","tools"]}`))
+		case "/v1/models/legacy":
+			w.Write([]byte(`{"id":"legacy"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		model   string
+		want    bool
+		wantErr error
+	}{
+		{"titan-text", false, nil},
+		{"claude:0", true, nil},
+		{"legacy", false, errCapabilitiesUnavailable},
+		{"unknown", false, errCapabilitiesUnavailable},
+	}
+	for _, tt := range tests {
+		got, err := checkModelSupportsTools(context.Background(), srv.Client(), srv.URL+"/", tt.model)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got (%v, %v), want (%v, %v)", tt.model, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if requestedPath != "/v1/models/unknown" {
+		t.Errorf("last request path = %s", requestedPath)
+	}
+}
+
+func TestCheckModelSupportsToolsServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	_, err := checkModelSupportsTools(context.Background(), srv.Client(), srv.URL, "m")
+	if err == nil || errors.Is(err, errCapabilitiesUnavailable) {
+		t.Errorf("err = %v, want an unexpected status error", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Clipboard reads and writes the system clipboard
+type Clipboard interface {
+	Read() (string, error)
+	Write(text string) error
+}
+
+// clipboard is the platform clipboard, replaceable for testing
+var clipboard Clipboard = systemClipboard{}
+
+// readFromClipboard returns the trimmed clipboard contents, or an error when it is empty
+func readFromClipboard() (string, error) {
+	text, err := clipboard.Read()
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("clipboard is empty")
+	}
+	return text, nil
+}
+
+// writeToClipboard copies text to the clipboard
+func writeToClipboard(text string) error {
+	return clipboard.Write(text)
+}
+
+// runClipboardCommand runs a clipboard helper, feeding it stdin when given, and returns its stdout
+func runClipboardCommand(stdin *string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = strings.NewReader(*stdin)
+	}
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+package main
+
+// systemClipboard uses pbpaste and pbcopy
+type systemClipboard struct{}
+
+func (systemClipboard) Read() (string, error) {
+	return runClipboardCommand(nil, "pbpaste")
+}
+
+func (systemClipboard) Write(text string) error {
+	_, err := runClipboardCommand(&text, "pbcopy")
+	return err
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// systemClipboard uses wl-clipboard under Wayland, otherwise xclip or xsel
+type systemClipboard struct{}
+
+func (systemClipboard) Read() (string, error) {
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "" && hasCommand("wl-paste"):
+		return runClipboardCommand(nil, "wl-paste", "--no-newline")
+	case hasCommand("xclip"):
+		return runClipboardCommand(nil, "xclip", "-selection", "clipboard", "-o")
+	case hasCommand("xsel"):
+		return runClipboardCommand(nil, "xsel", "--clipboard", "--output")
+	}
+	return "", errNoClipboardTool
+}
+
+func (systemClipboard) Write(text string) error {
+	var err error
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "" && hasCommand("wl-copy"):
+		_, err = runClipboardCommand(&text, "wl-copy")
+	case hasCommand("xclip"):
+		_, err = runClipboardCommand(&text, "xclip", "-selection", "clipboard")
+	case hasCommand("xsel"):
+		_, err = runClipboardCommand(&text, "xsel", "--clipboard", "--input")
+	default:
+		err = errNoClipboardTool
+	}
+	return err
+}
+
+var errNoClipboardTool = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
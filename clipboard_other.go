@@ -0,0 +1,16 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+import "errors"
+
+// systemClipboard is unavailable on this platform
+type systemClipboard struct{}
+
+func (systemClipboard) Read() (string, error) {
+	return "", errors.New("clipboard is not supported on this platform")
+}
+
+func (systemClipboard) Write(text string) error {
+	return errors.New("clipboard is not supported on this platform")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeClipboard is an in-memory Clipboard
+type fakeClipboard struct {
+	text    string
+	readErr error
+	writes  []string
+}
+
+func (c *fakeClipboard) Read() (string, error) { return c.text, c.readErr }
+
+func (c *fakeClipboard) Write(text string) error {
+	c.writes = append(c.writes, text)
+	c.text = text
+	return nil
+}
+
+func useFakeClipboard(t *testing.T, fake *fakeClipboard) {
+	t.Helper()
+	setFlag[Clipboard](t, &clipboard, fake)
+}
+
+func TestReadFromClipboard(t *testing.T) {
+	useFakeClipboard(t, &fakeClipboard{text: "  What is the weather in Paris?\n"})
+	got, err := readFromClipboard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "What is the weather in Paris?" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestReadFromClipboardEmpty(t *testing.T) {
+	for _, fake := range []*fakeClipboard{{text: " \n\t"}, {readErr: errors.New("no display")}} {
+		useFakeClipboard(t, fake)
+		if _, err := readFromClipboard(); err == nil {
+			t.Errorf("readFromClipboard with %+v succeeded", fake)
+		}
+	}
+}
+
+func TestWriteToClipboard(t *testing.T) {
+	fake := &fakeClipboard{}
+	useFakeClipboard(t, fake)
+	if err := writeToClipboard("Sunny, 25°C"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.writes) != 1 || fake.writes[0] != "Sunny, 25°C" {
+		t.Errorf("clipboard writes = %q", fake.writes)
+	}
+}
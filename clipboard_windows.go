@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+// systemClipboard uses the PowerShell clipboard cmdlets
+type systemClipboard struct{}
+
+func (systemClipboard) Read() (string, error) {
+	return runClipboardCommand(nil, "powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw")
+}
+
+func (systemClipboard) Write(text string) error {
+	_, err := runClipboardCommand(&text, "powershell.exe", "-NoProfile", "-Command", "$input | Set-Clipboard")
+	return err
+}
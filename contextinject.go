@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	openai "github.com/openai/openai-go"
+)
+
+// Context injection strategies for -context-injection-strategy
+const (
+	injectSystemPrepend     = "system-prepend"
+	injectSystemAppend      = "system-append"
+	injectUserPrepend       = "user-prepend"
+	injectSeparateUser      = "separate-user"
+	injectSeparateAssistant = "separate-assistant"
+)
+
+// ContextInjector places injected context, such as retrieved RAG chunks, in the message list
+type ContextInjector struct {
+	Strategy string
+}
+
+// validateInjectionStrategy checks the -context-injection-strategy value
+func validateInjectionStrategy(strategy string) error {
+	switch strategy {
+	case injectSystemPrepend, injectSystemAppend, injectUserPrepend, injectSeparateUser, injectSeparateAssistant:
+		return nil
+	}
+	return fmt.Errorf("unknown context injection strategy %q", strategy)
+}
+
+// contextInjectionStrategyFor returns the strategy to inject context with. The system
+// strategies fall back to user-prepend with -no-system-prompt, which would otherwise
+// strip the context together with the system message.
+func contextInjectionStrategyFor(strategy string, noSystemPrompt bool) string {
+	if noSystemPrompt && (strategy == injectSystemPrepend || strategy == injectSystemAppend) {
+		return injectUserPrepend
+	}
+	return strategy
+}
+
+// Inject returns a copy of messages with text added according to the strategy. The
+// user strategies work on the last user message, which is the current question.
+func (c ContextInjector) Inject(messages []openai.ChatCompletionMessageParamUnion, text string) []openai.ChatCompletionMessageParamUnion {
+	if text == "" {
+		return messages
+	}
+	switch c.Strategy {
+	case injectSystemAppend:
+		out := append([]openai.ChatCompletionMessageParamUnion{}, messages...)
+		for i, msg := range out {
+			if messageRole(msg) == string(openai.ChatCompletionSystemMessageParamRoleSystem) {
+				out[i] = openai.SystemMessage(messageText(msg) + "\n\n" + text)
+				return out
+			}
+		}
+		return append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(text)}, out...)
+	case injectUserPrepend, injectSeparateUser, injectSeparateAssistant:
+		i := lastUserMessage(messages)
+		if i < 0 {
+			return append(append([]openai.ChatCompletionMessageParamUnion{}, messages...), openai.UserMessage(text))
+		}
+		out := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages)+1)
+		out = append(out, messages[:i]...)
+		switch c.Strategy {
+		case injectUserPrepend:
+			out = append(out, openai.UserMessage(text+"\n\n"+messageText(messages[i])))
+		case injectSeparateUser:
+			out = append(out, openai.UserMessage(text), messages[i])
+		case injectSeparateAssistant:
+			out = append(out, openai.AssistantMessage(text), messages[i])
+		}
+		return append(out, messages[i+1:]...)
+	default:
+		return prependSystemContext(messages, text)
+	}
+}
+
+// lastUserMessage returns the index of the last user message, or -1
+func lastUserMessage(messages []openai.ChatCompletionMessageParamUnion) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messageRole(messages[i]) == string(openai.ChatCompletionUserMessageParamRoleUser) {
+			return i
+		}
+	}
+	return -1
+}
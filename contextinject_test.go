@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestContextInjectorStrategies(t *testing.T) {
+	history := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are a weather bot."),
+		openai.UserMessage("Hello"),
+		openai.AssistantMessage("Hi!"),
+		openai.UserMessage("Weather?"),
+	}
+	tests := []struct {
+		strategy string
+		want     []requestMessage
+	}{
+		{injectSystemPrepend, []requestMessage{
+			{"system", "CONTEXT\n\nYou are a weather bot."}, {"user", "Hello"}, {"assistant", "Hi!"}, {"user", "Weather?"},
+		}},
+		{injectSystemAppend, []requestMessage{
+			{"system", "You are a weather bot.\n\nCONTEXT"}, {"user", "Hello"}, {"assistant", "Hi!"}, {"user", "Weather?"},
+		}},
+		{injectUserPrepend, []requestMessage{
+			{"system", "You are a weather bot."}, {"user", "Hello"}, {"assistant", "Hi!"}, {"user", "CONTEXT\n\nWeather?"},
+		}},
+		{injectSeparateUser, []requestMessage{
+			{"system", "You are a weather bot."}, {"user", "Hello"}, {"assistant", "Hi!"}, {"user", "CONTEXT"}, {"user", "Weather?"},
+		}},
+		{injectSeparateAssistant, []requestMessage{
+			{"system", "You are a weather bot."}, {"user", "Hello"}, {"assistant", "Hi!"}, {"assistant", "CONTEXT"}, {"user", "Weather?"},
+		}},
+	}
+	for _, tt := range tests {
+		got := ContextInjector{Strategy: tt.strategy}.Inject(history, "CONTEXT")
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d messages, want %d", tt.strategy, len(got), len(tt.want))
+			continue
+		}
+		for i, msg := range got {
+			if m := (requestMessage{messageRole(msg), messageText(msg)}); m != tt.want[i] {
+				t.Errorf("%s: message %d = %+v, want %+v", tt.strategy, i, m, tt.want[i])
+			}
+		}
+	}
+	if got := messageText(history[0]); got != "You are a weather bot." {
+		t.Errorf("Inject modified its input: %q", got)
+	}
+}
+
+func TestContextInjectionStrategyFor(t *testing.T) {
+	tests := []struct {
+		strategy       string
+		noSystemPrompt bool
+		want           string
+	}{
+		{injectSystemPrepend, false, injectSystemPrepend},
+		{injectSystemPrepend, true, injectUserPrepend},
+		{injectSystemAppend, true, injectUserPrepend},
+		{injectSeparateAssistant, true, injectSeparateAssistant},
+	}
+	for _, tt := range tests {
+		if got := contextInjectionStrategyFor(tt.strategy, tt.noSystemPrompt); got != tt.want {
+			t.Errorf("contextInjectionStrategyFor(%s, %v) = %s, want %s", tt.strategy, tt.noSystemPrompt, got, tt.want)
+		}
+	}
+}
+
+func TestRAGContextSurvivesNoSystemPrompt(t *testing.T) {
+	index := &TFIDFIndex{}
+	if err := index.Build([]Document{{Source: "weather.txt", Text: "New York weather today: sunny."}}); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, &ragIndex, index)
+	setFlag(t, noSystemPrompt, true)
+	setFlag(t, contextInjectionStrategy, injectSystemPrepend)
+	gateway := newTestGateway(t, testCompletion(t, "Sunny"))
+
+	_, _, err := runConversation(context.Background(), newTestClient(gateway.URL), stubToolRegistry(t, "get_weather", nil), conversationOptions{Question: "New York weather?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := decodeRequestMessages(t, gateway.Requests()[0])
+	if len(messages) != 1 || messages[0].Role != "user" {
+		t.Fatalf("request messages = %+v, want a single user message", messages)
+	}
+	if !strings.Contains(messages[0].Content, "New York weather today: sunny.") || !strings.HasSuffix(messages[0].Content, "New York weather?") {
+		t.Errorf("user message = %q, want the RAG context before the question", messages[0].Content)
+	}
+}
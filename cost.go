@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	openai "github.com/openai/openai-go"
+)
+
+// modelPrice is the USD price per million prompt and completion tokens
+type modelPrice struct {
+	Prompt, Completion float64
+}
+
+// modelPrices are matched against model names by substring, most specific first
+var modelPrices = []struct {
+	Match string
+	Price modelPrice
+}{
+	{"claude-3-5-haiku", modelPrice{0.80, 4}},
+	{"claude-3-5-sonnet", modelPrice{3, 15}},
+	{"claude-3-7-sonnet", modelPrice{3, 15}},
+	{"claude-3-haiku", modelPrice{0.25, 1.25}},
+	{"claude-3-opus", modelPrice{15, 75}},
+	{"gpt-4o-mini", modelPrice{0.15, 0.60}},
+	{"gpt-4o", modelPrice{2.50, 10}},
+}
+
+// estimateCost prices usage for model; unknown models cost 0
+func estimateCost(model string, usage openai.CompletionUsage) float64 {
+	for _, p := range modelPrices {
+		if strings.Contains(model, p.Match) {
+			return (float64(usage.PromptTokens)*p.Price.Prompt + float64(usage.CompletionTokens)*p.Price.Completion) / 1e6
+		}
+	}
+	return 0
+}
+
+// ModelCost is the running total for one model in the cost tracking file
+type ModelCost struct {
+	TotalPromptTokens     int64   `json:"total_prompt_tokens"`
+	TotalCompletionTokens int64   `json:"total_completion_tokens"`
+	TotalCostUSD          float64 `json:"total_cost_usd"`
+	RequestCount          int     `json:"request_count"`
+}
+
+// CostTracker accumulates per-model token usage and cost in a JSON file at Path
+type CostTracker struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// costTracker is set from -cost-tracking-file
+var costTracker *CostTracker
+
+// Record adds one request's usage and cost to the totals for model
+func (t *CostTracker) Record(model string, usage openai.CompletionUsage, cost float64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	costs, err := t.load()
+	if err != nil {
+		return err
+	}
+	c := costs[model]
+	c.TotalPromptTokens += usage.PromptTokens
+	c.TotalCompletionTokens += usage.CompletionTokens
+	c.TotalCostUSD += cost
+	c.RequestCount++
+	costs[model] = c
+
+	data, err := json.MarshalIndent(costs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(t.Path, data)
+}
+
+// Report returns a table of the models sorted by total cost, most expensive first
+func (t *CostTracker) Report() string {
+	t.mu.Lock()
+	costs, err := t.load()
+	t.mu.Unlock()
+	if err != nil {
+		return fmt.Sprintf("Error reading %s: %v\n", t.Path, err)
+	}
+
+	models := make([]string, 0, len(costs))
+	for model := range costs {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if costs[models[i]].TotalCostUSD != costs[models[j]].TotalCostUSD {
+			return costs[models[i]].TotalCostUSD > costs[models[j]].TotalCostUSD
+		}
+		return models[i] < models[j]
+	})
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tREQUESTS\tPROMPT TOKENS\tCOMPLETION TOKENS\tCOST (USD)")
+	total := 0.0
+	for _, model := range models {
+		c := costs[model]
+		total += c.TotalCostUSD
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.4f\n", model, c.RequestCount, c.TotalPromptTokens, c.TotalCompletionTokens, c.TotalCostUSD)
+	}
+	fmt.Fprintf(w, "TOTAL\t\t\t\t%.4f\n", total)
+	w.Flush()
+	return b.String()
+}
+
+// load reads the totals; a missing file has none
+func (t *CostTracker) load() (map[string]ModelCost, error) {
+	costs := map[string]ModelCost{}
+	data, err := os.ReadFile(t.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return costs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &costs); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", t.Path, err)
+	}
+	return costs, nil
+}
+
+// trackCost records resp's usage when -cost-tracking-file is set
+func trackCost(resp *openai.ChatCompletion) {
+	if costTracker == nil || resp == nil {
+		return
+	}
+	if err := costTracker.Record(resp.Model, resp.Usage, estimateCost(resp.Model, resp.Usage)); err != nil {
+		log.Printf("Warning: failed to record cost: %v", err)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// runCostReport implements the cost-report subcommand
+func runCostReport(args []string) error {
+	fs := flag.NewFlagSet("cost-report", flag.ExitOnError)
+	path := fs.String("cost-tracking-file", "", "Cost tracking file written by -cost-tracking-file")
+	fs.Parse(args)
+
+	if *path == "" {
+		return errors.New("cost-report: -cost-tracking-file is required")
+	}
+	tracker := &CostTracker{Path: *path}
+	if _, err := tracker.load(); err != nil {
+		return err
+	}
+	fmt.Print(tracker.Report())
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestEstimateCost(t *testing.T) {
+	usage := openai.CompletionUsage{PromptTokens: 1000, CompletionTokens: 500}
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"gpt-4o", 0.0075},
+		{"gpt-4o-mini-2024-07-18", 0.00045},
+		{"anthropic.claude-3-5-sonnet-20240620-v1:0", 0.0105},
+		{"unknown-model", 0},
+	}
+	for _, tt := range tests {
+		if got := estimateCost(tt.model, usage); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("estimateCost(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestCostTrackerConcurrentRecord(t *testing.T) {
+	tracker := &CostTracker{Path: filepath.Join(t.TempDir(), "costs.json")}
+	usage := openai.CompletionUsage{PromptTokens: 1000, CompletionTokens: 500}
+
+	var wg sync.WaitGroup
+	for _, model := range []string{"gpt-4o", "gpt-4o"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tracker.Record(model, usage, estimateCost(model, usage)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	costs, err := tracker.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ModelCost{TotalPromptTokens: 2000, TotalCompletionTokens: 1000, TotalCostUSD: 0.015, RequestCount: 2}
+	if got := costs["gpt-4o"]; got.TotalPromptTokens != want.TotalPromptTokens ||
+		got.TotalCompletionTokens != want.TotalCompletionTokens || got.RequestCount != want.RequestCount ||
+		math.Abs(got.TotalCostUSD-want.TotalCostUSD) > 1e-12 {
+		t.Errorf("gpt-4o totals = %+v, want %+v", got, want)
+	}
+
+	if err := tracker.Record("claude-3-5-sonnet", usage, estimateCost("claude-3-5-sonnet", usage)); err != nil {
+		t.Fatal(err)
+	}
+	report := strings.Split(strings.TrimSpace(tracker.Report()), "\n")
+	if len(report) != 4 {
+		t.Fatalf("report has %d lines, want 4:\n%s", len(report), strings.Join(report, "\n"))
+	}
+	if !strings.HasPrefix(report[1], "gpt-4o ") || !strings.HasPrefix(report[2], "claude-3-5-sonnet ") {
+		t.Errorf("report not sorted by cost:\n%s", strings.Join(report, "\n"))
+	}
+	if fields := strings.Fields(report[3]); fields[0] != "TOTAL" || fields[len(fields)-1] != "0.0255" {
+		t.Errorf("total line = %q, want a 0.0255 total", report[3])
+	}
+}
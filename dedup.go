@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	openai "github.com/openai/openai-go"
+)
+
+// inflightCall holds the shared result of one deduplicated request
+type inflightCall struct {
+	once sync.Once
+	resp *openai.ChatCompletion
+	err  error
+}
+
+// InflightDeduplicator collapses identical requests that are in flight at the same
+// time into a single call; later callers wait for and share the first call's result
+type InflightDeduplicator struct {
+	calls sync.Map // key -> *inflightCall
+}
+
+// requestDeduplicator is shared by every completion request when -request-deduplication is set
+var requestDeduplicator = &InflightDeduplicator{}
+
+// Do runs fn once for all concurrent callers using the same key
+func (d *InflightDeduplicator) Do(key string, fn func() (*openai.ChatCompletion, error)) (*openai.ChatCompletion, error) {
+	v, _ := d.calls.LoadOrStore(key, &inflightCall{})
+	call := v.(*inflightCall)
+	call.once.Do(func() {
+		call.resp, call.err = fn()
+		// Only in-flight requests are shared; the next identical request is sent again
+		d.calls.Delete(key)
+	})
+	return call.resp, call.err
+}
+
+// deduplicationKey hashes the model and the canonical JSON of the messages
+func deduplicationKey(params openai.ChatCompletionNewParams) (string, error) {
+	messages, err := json.Marshal(params.Messages.Value)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(params.Model.Value), messages...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestRequestDeduplication(t *testing.T) {
+	setFlag(t, requestDeduplication, true)
+	var calls int32
+	mock := NewMockGatewayServer(0, []openai.ChatCompletion{testCompletion(t, "Sunny")}).Handler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	client := newTestClient(srv.URL)
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}),
+		Model:    openai.F("test-model"),
+	}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	results := make([]*openai.ChatCompletion, 5)
+	errs := make([]error, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = createCompletion(context.Background(), client, params)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("mock gateway was called %d times, want 1", got)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("request %d: %v", i, errs[i])
+		}
+		if results[i].Choices[0].Message.Content != "Sunny" {
+			t.Errorf("request %d got %q", i, results[i].Choices[0].Message.Content)
+		}
+	}
+
+	// Once the first request is done the next identical one is sent again
+	if _, err := createCompletion(context.Background(), client, params); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("mock gateway was called %d times after a later request, want 2", got)
+	}
+}
+
+func TestDeduplicationKey(t *testing.T) {
+	params := func(model, question string) openai.ChatCompletionNewParams {
+		return openai.ChatCompletionNewParams{
+			Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage(question)}),
+			Model:    openai.F(model),
+		}
+	}
+	a, _ := deduplicationKey(params("gpt-4o", "Weather?"))
+	b, _ := deduplicationKey(params("gpt-4o", "Weather?"))
+	c, _ := deduplicationKey(params("gpt-4o-mini", "Weather?"))
+	d, _ := deduplicationKey(params("gpt-4o", "Time?"))
+	if a != b {
+		t.Error("identical requests have different keys")
+	}
+	if a == c || a == d {
+		t.Error("requests differing in model or messages share a key")
+	}
+}
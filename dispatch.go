@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	openai "github.com/openai/openai-go"
+)
+
+// handleToolCall runs a single tool call and returns its tool message. Failures are
+// reported to the model as the tool result rather than aborting the conversation.
+func handleToolCall(ctx context.Context, registry *ToolRegistry, toolCall openai.ChatCompletionMessageToolCall) openai.ChatCompletionToolMessageParam {
+	name := toolCall.Function.Name
+	argsHash := hashToolArguments(toolCall.Function.Arguments)
+
+	// The cache holds raw tool results; the annotations below are applied to cached and
+	// fresh results alike
+	result, cached := "", false
+	if registry.Cache != nil {
+		if result, cached = registry.Cache.Lookup(name, argsHash); cached {
+			log.Printf("Reusing cached result for %s", name)
+		}
+	}
+	if !cached {
+		var err error
+		result, err = dispatchToolCall(ctx, registry, toolCall)
+		if err != nil {
+			log.Printf("Error calling tool %s: %v", name, err)
+			return openai.ToolMessage(toolCall.ID, fmt.Sprintf("Error: %v", err))
+		}
+		if registry.Cache != nil {
+			registry.Cache.Record(name, argsHash, result)
+		}
+	}
+
+	if registry.Verify != nil {
+		result = verifiedResult(ctx, registry, name, result)
+	}
+	if *promptInjectionDetection {
+		if detected, pattern := detectInjection(result); detected {
+			log.Printf("SECURITY WARNING: potential prompt injection in %s result (matched %q), redacting", name, pattern)
+			result = injectionRedactedResult
+		}
+	}
+	if *toolResultLanguageDetect {
+		if note := languageNote(result, *expectedLanguage); note != "" {
+			result = note + " " + result
+		}
+	}
+	return openai.ToolMessage(toolCall.ID, result)
+}
+
+// verifiedResult runs registry.Verify on result, prefixing it with unverifiedResultPrefix
+// when the verifier rejects it. Verification errors leave the result unchanged.
+func verifiedResult(ctx context.Context, registry *ToolRegistry, name, result string) string {
+	verified, reason, err := registry.Verify(ctx, result)
+	switch {
+	case err != nil:
+		log.Printf("Warning: could not verify %s result: %v", name, err)
+	case !verified:
+		log.Printf("Warning: %s result failed verification: %s", name, reason)
+		return unverifiedResultPrefix + result
+	}
+	return result
+}
+
+// dispatchToolCallsOrdered runs the calls one at a time in the order the model listed them
+func dispatchToolCallsOrdered(ctx context.Context, calls []openai.ChatCompletionMessageToolCall, registry *ToolRegistry) ([]openai.ChatCompletionMessageParamUnion, error) {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(calls))
+	for _, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		messages = append(messages, handleToolCall(ctx, registry, call))
+	}
+	return messages, nil
+}
+
+// dispatchToolCallsConcurrently runs all calls at once; results keep the order of calls
+func dispatchToolCallsConcurrently(ctx context.Context, calls []openai.ChatCompletionMessageToolCall, registry *ToolRegistry) ([]openai.ChatCompletionMessageParamUnion, error) {
+	messages := make([]openai.ChatCompletionMessageParamUnion, len(calls))
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call openai.ChatCompletionMessageToolCall) {
+			defer wg.Done()
+			if registry.ParallelLimit != nil {
+				release, err := registry.ParallelLimit.Acquire(ctx, call.Function.Name)
+				if err != nil {
+					return
+				}
+				defer release()
+			}
+			messages[i] = handleToolCall(ctx, registry, call)
+		}(i, call)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
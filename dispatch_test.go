@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// sleepyRegistry registers tool_1..tool_n, where tool_i sleeps delays[i-1] before answering
+func sleepyRegistry(t *testing.T, delays ...time.Duration) *ToolRegistry {
+	t.Helper()
+	registry := NewToolRegistry(0)
+	for i, delay := range delays {
+		name, delay := fmt.Sprintf("tool_%d", i+1), delay
+		err := registry.Register(Tool{
+			Name:       name,
+			Parameters: openai.FunctionParameters{"type": "object", "properties": map[string]interface{}{}},
+			Handler: func(context.Context, map[string]interface{}) (string, error) {
+				time.Sleep(delay)
+				return name + " result", nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return registry
+}
+
+func TestDispatchKeepsCallOrder(t *testing.T) {
+	// tool_3 finishes first and tool_1 last
+	registry := sleepyRegistry(t, 60*time.Millisecond, 30*time.Millisecond, 0)
+	calls := testToolCallsNamed("tool_1", "tool_2", "tool_3")
+	dispatchers := map[string]func(context.Context, []openai.ChatCompletionMessageToolCall, *ToolRegistry) ([]openai.ChatCompletionMessageParamUnion, error){
+		"ordered":      dispatchToolCallsOrdered,
+		"concurrently": dispatchToolCallsConcurrently,
+	}
+	for name, dispatch := range dispatchers {
+		t.Run(name, func(t *testing.T) {
+			messages, err := dispatch(context.Background(), calls, registry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(messages) != len(calls) {
+				t.Fatalf("got %d messages, want %d", len(messages), len(calls))
+			}
+			for i, msg := range messages {
+				if got, want := toolCallID(msg), calls[i].ID; got != want {
+					t.Errorf("message %d answers %s, want %s", i, got, want)
+				}
+				if got, want := messageText(msg), fmt.Sprintf("tool_%d result", i+1); got != want {
+					t.Errorf("message %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchToolCallsOrderedCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := dispatchToolCallsOrdered(ctx, testToolCallsNamed("tool_1"), sleepyRegistry(t, 0)); err == nil {
+		t.Error("a cancelled dispatch returned no error")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+const enhancePromptPrefix = "Improve this tool description for an AI assistant in 1-2 sentences: "
+
+// enhancedDescription is a -enhance-descriptions-cache entry; it is reused only while
+// the tool's original description is unchanged
+type enhancedDescription struct {
+	Original string `json:"original"`
+	Enhanced string `json:"enhanced"`
+}
+
+// enhanceToolDescriptions asks model to rewrite each tool description and updates the
+// registry. Descriptions found in -enhance-descriptions-cache are reused without a call.
+func enhanceToolDescriptions(ctx context.Context, client *openai.Client, model string, registry *ToolRegistry) error {
+	cache, err := loadDescriptionCache(*enhanceDescriptionsCache)
+	if err != nil {
+		return fmt.Errorf("loading description cache: %w", err)
+	}
+	changed := false
+	for _, tool := range registry.Tools() {
+		if entry, ok := cache[tool.Name]; ok && entry.Original == tool.Description {
+			tool.Description = entry.Enhanced
+			continue
+		}
+		enhanced, err := enhanceDescription(ctx, client, model, tool.Description)
+		if err != nil {
+			return fmt.Errorf("enhancing description of %s: %w", tool.Name, err)
+		}
+		cache[tool.Name] = enhancedDescription{Original: tool.Description, Enhanced: enhanced}
+		tool.Description = enhanced
+		changed = true
+	}
+	if changed && *enhanceDescriptionsCache != "" {
+		if err := saveDescriptionCache(*enhanceDescriptionsCache, cache); err != nil {
+			log.Printf("Warning: failed to save description cache: %v", err)
+		}
+	}
+	return nil
+}
+
+// enhanceDescription asks model for a clearer version of description
+func enhanceDescription(ctx context.Context, client *openai.Client, model, description string) (string, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(enhancePromptPrefix + description),
+		}),
+		Model:     openai.F(model),
+		MaxTokens: openai.Int(200),
+	}
+	resp, err := sendRequest(ctx, client, params)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("enhancement response has no choices")
+	}
+	enhanced := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if enhanced == "" {
+		return "", errors.New("enhancement response is empty")
+	}
+	return enhanced, nil
+}
+
+// loadDescriptionCache reads the cache at path; an unset path or missing file is an empty cache
+func loadDescriptionCache(path string) (map[string]enhancedDescription, error) {
+	cache := map[string]enhancedDescription{}
+	if path == "" {
+		return cache, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func saveDescriptionCache(path string, cache map[string]enhancedDescription) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
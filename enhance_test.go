@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+const enhancedWeatherDescription = "Returns the current weather conditions and temperature for a city or place name."
+
+func TestEnhanceToolDescriptions(t *testing.T) {
+	setFlag(t, enhanceDescriptionsCache, filepath.Join(t.TempDir(), "descriptions.json"))
+	gateway := newTestGateway(t, testCompletion(t, "  "+enhancedWeatherDescription+"\n"))
+	client := newTestClient(gateway.URL)
+
+	registry, err := newDefaultToolRegistry(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool, _ := registry.Get("get_weather")
+	original := tool.Description
+	if err := enhanceToolDescriptions(context.Background(), client, "test-model", registry); err != nil {
+		t.Fatal(err)
+	}
+	requests := gateway.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("gateway received %d requests, want 1", len(requests))
+	}
+	if got := decodeRequestMessages(t, requests[0]); len(got) != 1 || got[0].Content != enhancePromptPrefix+original {
+		t.Errorf("enhancement prompt = %+v", got)
+	}
+	if tool.Description != enhancedWeatherDescription {
+		t.Errorf("description = %q, want %q", tool.Description, enhancedWeatherDescription)
+	}
+
+	// A fresh registry is enhanced from the cache without calling the model
+	registry, err = newDefaultToolRegistry(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enhanceToolDescriptions(context.Background(), client, "test-model", registry); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(gateway.Requests()); n != 1 {
+		t.Errorf("cached run sent %d more requests", n-1)
+	}
+	if tool, _ := registry.Get("get_weather"); tool.Description != enhancedWeatherDescription {
+		t.Errorf("cached description = %q", tool.Description)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// validateExitCodePatterns checks that the -success-pattern and -failure-pattern regexes compile
+func validateExitCodePatterns(successPattern, failurePattern string) error {
+	for _, p := range []string{successPattern, failurePattern} {
+		if p == "" {
+			continue
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// determineExitCode maps the model response to a process exit code: 1 when text matches
+// failurePattern, otherwise 0. failurePattern takes precedence when both match and an
+// empty pattern never matches.
+func determineExitCode(text, successPattern, failurePattern string) int {
+	if failurePattern != "" {
+		if matched, _ := regexp.MatchString(failurePattern, text); matched {
+			return 1
+		}
+	}
+	if successPattern != "" {
+		if matched, _ := regexp.MatchString(successPattern, text); matched {
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestDetermineExitCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		success string
+		failure string
+		want    int
+	}{
+		{"success matches", "Deployment PASSED", "PASSED", "FAILED", 0},
+		{"failure matches", "Deployment FAILED", "PASSED", "FAILED", 1},
+		{"neither matches", "Deployment pending", "PASSED", "FAILED", 0},
+		{"both match, failure wins", "PASSED 3 checks, FAILED 1", "PASSED", "FAILED", 1},
+		{"no patterns", "FAILED", "", "", 0},
+		{"regex failure pattern", "error: exit status 2", "", `(?i)^error:`, 1},
+	}
+	for _, tt := range tests {
+		if got := determineExitCode(tt.text, tt.success, tt.failure); got != tt.want {
+			t.Errorf("%s: determineExitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateExitCodePatterns(t *testing.T) {
+	if err := validateExitCodePatterns("PASSED", ""); err != nil {
+		t.Errorf("valid patterns rejected: %v", err)
+	}
+	if err := validateExitCodePatterns("PASSED", "("); err == nil {
+		t.Error("invalid failure pattern accepted")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// FallbackToolHandler serves a tool from the first of URLs that answers. A URL fails
+// on a network error or non-2xx status; whatever body a 2xx response has is returned.
+type FallbackToolHandler struct {
+	ToolName string
+	URLs     []string
+	Fetch    ToolFetcher
+}
+
+// Handle tries each URL in order and returns the first successful result
+func (h *FallbackToolHandler) Handle(ctx context.Context, args map[string]interface{}) (string, error) {
+	var errs []error
+	for _, u := range h.URLs {
+		result, err := h.Fetch(ctx, u, args)
+		if err == nil {
+			log.Printf("Tool %s served by %s", h.ToolName, u)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		log.Printf("Warning: tool %s failed at %s: %v", h.ToolName, u, err)
+		errs = append(errs, fmt.Errorf("%s: %w", u, err))
+	}
+	return "", fmt.Errorf("all %d URLs failed: %w", len(h.URLs), errors.Join(errs...))
+}
+
+// applyToolFallbackChain parses the -tool-fallback-chain JSON object of tool name to
+// URLs and installs a FallbackToolHandler for each listed tool
+func applyToolFallbackChain(registry *ToolRegistry, value string) error {
+	if value == "" {
+		return nil
+	}
+	var chains map[string][]string
+	if err := json.Unmarshal([]byte(value), &chains); err != nil {
+		return err
+	}
+	for name, urls := range chains {
+		tool, ok := registry.Get(name)
+		if !ok {
+			return fmt.Errorf("unknown tool %q", name)
+		}
+		if tool.Fetch == nil {
+			return fmt.Errorf("tool %q cannot be fetched from a URL", name)
+		}
+		if len(urls) == 0 {
+			return fmt.Errorf("tool %q has no URLs", name)
+		}
+		handler := &FallbackToolHandler{ToolName: name, URLs: urls, Fetch: tool.Fetch}
+		tool.Handler = handler.Handle
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// statusServer answers every request with status and body, counting the requests
+func statusServer(t *testing.T, status int, body string, hits *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestToolFallbackChain(t *testing.T) {
+	var hits [3]int32
+	primary := statusServer(t, http.StatusServiceUnavailable, "unavailable", &hits[0])
+	backup := statusServer(t, http.StatusServiceUnavailable, "unavailable", &hits[1])
+	last := statusServer(t, http.StatusOK, "Sunny, 25°C", &hits[2])
+
+	registry, err := newDefaultToolRegistry(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := fmt.Sprintf(`{"get_weather": [%q, %q, %q]}`, primary.URL, backup.URL, last.URL)
+	if err := applyToolFallbackChain(registry, chain); err != nil {
+		t.Fatal(err)
+	}
+	result, err := dispatchToolCall(context.Background(), registry, testToolCall("call_1", "get_weather", `{"location":"New York"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "Sunny, 25°C" {
+		t.Errorf("result = %q, want the third URL's response", result)
+	}
+	for i, n := range hits {
+		if n != 1 {
+			t.Errorf("URL %d was called %d times, want 1", i+1, n)
+		}
+	}
+}
+
+func TestToolFallbackChainAllFail(t *testing.T) {
+	var hits int32
+	down := statusServer(t, http.StatusServiceUnavailable, "unavailable", &hits)
+	handler := &FallbackToolHandler{ToolName: "get_weather", URLs: []string{down.URL, down.URL}, Fetch: fetchWeatherTool}
+	if _, err := handler.Handle(context.Background(), map[string]interface{}{"location": "New York"}); err == nil {
+		t.Error("Handle succeeded with every URL returning 503")
+	}
+	if hits != 2 {
+		t.Errorf("server was called %d times, want 2", hits)
+	}
+}
+
+func TestApplyToolFallbackChainErrors(t *testing.T) {
+	for _, bad := range []string{`{"unknown_tool": ["http://a"]}`, `{"get_weather": []}`, `[`} {
+		registry, err := newDefaultToolRegistry(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := applyToolFallbackChain(registry, bad); err == nil {
+			t.Errorf("applyToolFallbackChain(%s) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockPollInterval is how often Lock retries a lock held by another process
+const lockPollInterval = 50 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("file is locked")
+
+// FileLock is an exclusive advisory lock on the file at Path, shared across processes
+type FileLock struct {
+	Path string
+
+	file *os.File
+}
+
+// Lock blocks until the lock is acquired or ctx is done
+func (l *FileLock) Lock(ctx context.Context) error {
+	file, err := os.OpenFile(l.Path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	for {
+		err := tryLock(file)
+		if err == nil {
+			l.file = file
+			return nil
+		}
+		if !errors.Is(err, errLocked) {
+			file.Close()
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return fmt.Errorf("waiting for lock %s: %w", l.Path, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	if l.file == nil {
+		return errors.New("lock is not held")
+	}
+	err := unlockFile(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
+
+// lockSession takes the -conversation-lock lock for the session at path, waiting up to
+// -lock-timeout. The returned func releases it; without -conversation-lock it does nothing.
+func lockSession(path string) (func(), error) {
+	if !*conversationLock {
+		return func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *lockTimeout)
+	defer cancel()
+	lock := &FileLock{Path: path + ".lock"}
+	if err := lock.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return func() { lock.Unlock() }, nil
+}
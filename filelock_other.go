@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLock(file *os.File) error {
+	return errors.New("file locking is not supported on this platform")
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileLockTimesOutWhileHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json.lock")
+	first := &FileLock{Path: path}
+	if err := first.Lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	second := &FileLock{Path: path}
+	start := time.Now()
+	err := second.Lock(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Lock = %v, want a deadline exceeded error", err)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("second Lock gave up after %v, before its timeout", waited)
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Lock(context.Background()); err != nil {
+		t.Fatalf("Lock after Unlock: %v", err)
+	}
+	if err := second.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Unlock(); err == nil {
+		t.Error("unlocking a lock that is not held succeeded")
+	}
+}
+
+// lockHelperEnv names the lock file TestFileLockHelperProcess tries to take
+const lockHelperEnv = "FILELOCK_HELPER_PATH"
+
+// TestFileLockHelperProcess is run as a separate process by TestFileLockAcrossProcesses
+func TestFileLockHelperProcess(t *testing.T) {
+	path := os.Getenv(lockHelperEnv)
+	if path == "" {
+		t.Skip("only run as a helper process")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	lock := &FileLock{Path: path}
+	if err := lock.Lock(ctx); err != nil {
+		os.Exit(3)
+	}
+	lock.Unlock()
+}
+
+func TestFileLockAcrossProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json.lock")
+	lock := &FileLock{Path: path}
+	if err := lock.Lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFileLockHelperProcess$")
+	cmd.Env = append(os.Environ(), lockHelperEnv+"="+path)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("helper process = %v, want it to time out waiting for the lock", err)
+	}
+}
+
+func TestLoadSessionTimesOutOnLockedSession(t *testing.T) {
+	setFlag(t, conversationLock, true)
+	setFlag(t, lockTimeout, 100*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "session.json")
+	lock := &FileLock{Path: path + ".lock"}
+	if err := lock.Lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+	if _, err := loadSession(path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("loadSession = %v, want a lock timeout", err)
+	}
+}
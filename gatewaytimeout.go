@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// withGatewayTimeout bounds a single gateway call to ms milliseconds; ms <= 0 leaves parent unchanged
+func withGatewayTimeout(parent context.Context, ms int) (context.Context, context.CancelFunc) {
+	if ms > 0 {
+		return context.WithTimeout(parent, time.Duration(ms)*time.Millisecond)
+	}
+	return parent, func() {}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestGatewayTimeout(t *testing.T) {
+	mock := NewMockGatewayServer(0, []openai.ChatCompletion{testCompletion(t, "Sunny")}).Handler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	client := newTestClient(srv.URL)
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}),
+		Model:    openai.F("test-model"),
+	}
+
+	tests := []struct {
+		timeoutMs int
+		wantErr   bool
+	}{
+		{50, true},
+		{500, false},
+		{0, false},
+	}
+	for _, tt := range tests {
+		setFlag(t, gatewayTimeoutMs, tt.timeoutMs)
+		_, err := sendRequest(context.Background(), client, params)
+		if tt.wantErr && !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("timeout %dms: err = %v, want a deadline exceeded error", tt.timeoutMs, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("timeout %dms: %v", tt.timeoutMs, err)
+		}
+	}
+}
+
+func TestWithGatewayTimeoutLeavesParentWithoutLimit(t *testing.T) {
+	parent := context.Background()
+	ctx, cancel := withGatewayTimeout(parent, 0)
+	defer cancel()
+	if ctx != parent {
+		t.Error("a zero timeout derived a new context")
+	}
+	ctx, cancel = withGatewayTimeout(parent, 50)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("a 50ms timeout set no deadline")
+	}
+}
@@ -3,7 +3,14 @@ module platform-demo-kubecon-eu-2025
 go 1.22.4
 
 require (
-	github.com/openai/openai-go v0.1.0-alpha.59 // indirect
+	github.com/openai/openai-go v0.1.0-alpha.59
+	github.com/sergi/go-diff v1.3.1
+	golang.org/x/net v0.34.0
+	golang.org/x/sys v0.29.0
+	golang.org/x/term v0.28.0
+)
+
+require (
 	github.com/tidwall/gjson v1.14.4 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
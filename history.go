@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// maxPromptHistory is the number of questions kept in the prompt history
+const maxPromptHistory = 1000
+
+// PromptHistory holds previously asked questions, oldest first. Cursor points at the
+// entry shown by the last Previous/Next call; len(Entries) is the new, empty line.
+type PromptHistory struct {
+	Entries []string
+	Cursor  int
+}
+
+// Previous moves to the next older entry and returns it, staying on the oldest entry
+func (h *PromptHistory) Previous() string {
+	if len(h.Entries) == 0 {
+		return ""
+	}
+	if h.Cursor > 0 {
+		h.Cursor--
+	}
+	return h.Entries[h.Cursor]
+}
+
+// Next moves to the next newer entry and returns it, or "" once past the newest entry
+func (h *PromptHistory) Next() string {
+	if h.Cursor < len(h.Entries) {
+		h.Cursor++
+	}
+	if h.Cursor == len(h.Entries) {
+		return ""
+	}
+	return h.Entries[h.Cursor]
+}
+
+// Add appends entry, dropping the oldest entries beyond maxPromptHistory, and resets
+// the cursor to the new line. Blank entries and repeats of the last entry are skipped.
+func (h *PromptHistory) Add(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry != "" && (len(h.Entries) == 0 || h.Entries[len(h.Entries)-1] != entry) {
+		h.Entries = append(h.Entries, entry)
+		if over := len(h.Entries) - maxPromptHistory; over > 0 {
+			h.Entries = append([]string(nil), h.Entries[over:]...)
+		}
+	}
+	h.Cursor = len(h.Entries)
+}
+
+// loadPromptHistory reads one entry per line from path; a missing file is an empty history
+func loadPromptHistory(path string) (*PromptHistory, error) {
+	h := &PromptHistory{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		h.Add(line)
+	}
+	return h, nil
+}
+
+// savePromptHistory writes the entries to path, one per line
+func savePromptHistory(path string, h *PromptHistory) error {
+	var b strings.Builder
+	for _, entry := range h.Entries {
+		b.WriteString(entry)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestPromptHistoryNavigation(t *testing.T) {
+	var h PromptHistory
+	if got := h.Previous(); got != "" {
+		t.Errorf("Previous on an empty history = %q", got)
+	}
+	for _, q := range []string{"weather?", "  ", "time?", "time?", "news?"} {
+		h.Add(q)
+	}
+	if len(h.Entries) != 3 || h.Cursor != 3 {
+		t.Fatalf("after Add: entries %q, cursor %d", h.Entries, h.Cursor)
+	}
+
+	steps := []struct {
+		move func() string
+		want string
+	}{
+		{h.Previous, "news?"},
+		{h.Previous, "time?"},
+		{h.Previous, "weather?"},
+		{h.Previous, "weather?"},
+		{h.Next, "time?"},
+		{h.Next, "news?"},
+		{h.Next, ""},
+		{h.Next, ""},
+		{h.Previous, "news?"},
+	}
+	for i, step := range steps {
+		if got := step.move(); got != step.want {
+			t.Errorf("step %d = %q, want %q", i, got, step.want)
+		}
+	}
+
+	h.Add("forecast?")
+	if h.Cursor != len(h.Entries) {
+		t.Errorf("Add left the cursor at %d, want %d", h.Cursor, len(h.Entries))
+	}
+	if got := h.Previous(); got != "forecast?" {
+		t.Errorf("Previous after Add = %q", got)
+	}
+}
+
+func TestPromptHistoryLimit(t *testing.T) {
+	var h PromptHistory
+	for i := 0; i < maxPromptHistory+5; i++ {
+		h.Add(fmt.Sprintf("question %d", i))
+	}
+	if len(h.Entries) != maxPromptHistory {
+		t.Fatalf("history has %d entries, want %d", len(h.Entries), maxPromptHistory)
+	}
+	if h.Entries[0] != "question 5" {
+		t.Errorf("oldest entry = %q, want question 5", h.Entries[0])
+	}
+}
+
+func TestPromptHistoryFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h, err := loadPromptHistory(path)
+	if err != nil || len(h.Entries) != 0 {
+		t.Fatalf("missing file = %v, %v", h, err)
+	}
+	h.Add("weather?")
+	h.Add("time?")
+	if err := savePromptHistory(path, h); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadPromptHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(loaded.Entries) != "[weather? time?]" || loaded.Cursor != 2 {
+		t.Errorf("loaded entries %q, cursor %d", loaded.Entries, loaded.Cursor)
+	}
+}
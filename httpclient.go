@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+)
+
+// bedrockRegions are the regions used for direct Bedrock requests, first one first
+var bedrockRegions []string
+
+// buildHTTPClient returns the HTTP client used for AI Gateway or Bedrock calls
+func buildHTTPClient() (*http.Client, error) {
+	var transport http.RoundTripper = http.DefaultTransport
+	tlsConfig, err := gatewayTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		base := http.DefaultTransport.(*http.Transport).Clone()
+		base.TLSClientConfig = tlsConfig
+		transport = base
+	}
+	if !*useAIGateway {
+		transport = &BedrockRegionTransport{
+			Base:             transport,
+			Rotator:          &RegionRotator{Regions: bedrockRegions},
+			EndpointTemplate: *bedrockEndpointTemplate,
+			Credentials: awsCredentials{
+				AccessKeyID:  *awsAccessKeyID,
+				SecretKey:    *awsSecretKey,
+				SessionToken: *awsSessionToken,
+			},
+		}
+	}
+
+	transport = &RequestIDTransport{Base: transport, Header: *gatewayRequestIDHeader, ID: gatewayRequestIDValue()}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// gatewayTLSConfig returns the TLS config trusting -gateway-ca-bundle, or nil when no
+// bundle is set and the system defaults apply
+func gatewayTLSConfig() (*tls.Config, error) {
+	if *gatewayCABundle == "" {
+		return nil, nil
+	}
+	pool, err := loadCABundle(*gatewayCABundle, *gatewayCABundleOverride)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// gatewayRequestIDValue returns the fixed request ID from -gateway-request-id or the
+// -gateway-request-id-env variable, or an empty string to generate one per request
+func gatewayRequestIDValue() string {
+	if *gatewayRequestIDEnv != "" {
+		if id := os.Getenv(*gatewayRequestIDEnv); id != "" {
+			return id
+		}
+	}
+	return *gatewayRequestID
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// bedrockControlEndpointTemplate is the Bedrock control plane endpoint, which serves
+// the inference profile API
+const bedrockControlEndpointTemplate = "https://bedrock.{region}.amazonaws.com"
+
+// InferenceProfile is one entry of the Bedrock ListInferenceProfiles response
+type InferenceProfile struct {
+	ID     string `json:"inferenceProfileId"`
+	Name   string `json:"inferenceProfileName"`
+	ARN    string `json:"inferenceProfileArn"`
+	Status string `json:"status"`
+	Type   string `json:"type"`
+}
+
+// resolveModelID returns the -aws-bedrock-inference-profile ID or ARN when set, so
+// requests are routed across regions by the profile, and modelName otherwise
+func resolveModelID(modelName, inferenceProfile string) string {
+	if inferenceProfile != "" {
+		return inferenceProfile
+	}
+	return modelName
+}
+
+// runInferenceProfileList implements the inference-profile-list subcommand. The AWS
+// flags of the main command (-aws-region, -aws-credentials-file, ...) are accepted as well.
+func runInferenceProfileList(args []string) error {
+	fs := newSubcommandFlagSet("inference-profile-list")
+	endpoint := fs.String("endpoint-template", bedrockControlEndpointTemplate, "Bedrock control plane endpoint; {region} is replaced with -aws-region")
+	fs.Parse(args)
+
+	if err := applyAWSCredentialsFile(); err != nil {
+		return fmt.Errorf("loading AWS credentials: %w", err)
+	}
+	creds := awsCredentials{AccessKeyID: *awsAccessKeyID, SecretKey: *awsSecretKey, SessionToken: *awsSessionToken}
+	profiles, err := listInferenceProfiles(context.Background(), http.DefaultClient, bedrockEndpoint(*endpoint, *awsRegion), *awsRegion, creds)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tSTATUS")
+	for _, p := range profiles {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.ID, p.Name, p.Type, p.Status)
+	}
+	return w.Flush()
+}
+
+// listInferenceProfiles calls GET /inference-profiles, following nextToken pages
+func listInferenceProfiles(ctx context.Context, client *http.Client, endpoint, region string, creds awsCredentials) ([]InferenceProfile, error) {
+	var profiles []InferenceProfile
+	nextToken := ""
+	for {
+		u, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/inference-profiles")
+		if err != nil {
+			return nil, err
+		}
+		if nextToken != "" {
+			u.RawQuery = url.Values{"nextToken": {nextToken}}.Encode()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		signV4(req, nil, creds, region, bedrockService, time.Now())
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("listing inference profiles: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		var page struct {
+			Profiles  []InferenceProfile `json:"inferenceProfileSummaries"`
+			NextToken string             `json:"nextToken"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("parsing inference profiles: %w", err)
+		}
+		profiles = append(profiles, page.Profiles...)
+		if page.NextToken == "" {
+			return profiles, nil
+		}
+		nextToken = page.NextToken
+	}
+}
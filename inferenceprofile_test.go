@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResolveModelID(t *testing.T) {
+	const profile = "us.anthropic.claude-3-5-sonnet-20241022-v2:0"
+	if got := resolveModelID("anthropic.claude-3-5-sonnet-20241022-v2:0", ""); got != "anthropic.claude-3-5-sonnet-20241022-v2:0" {
+		t.Errorf("without a profile = %q, want the model name", got)
+	}
+	if got := resolveModelID("anthropic.claude-3-5-sonnet-20241022-v2:0", profile); got != profile {
+		t.Errorf("with a profile = %q, want the profile", got)
+	}
+}
+
+// newMockBedrockControlPlane serves /inference-profiles in two pages and records the
+// Authorization header of each request
+func newMockBedrockControlPlane(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var authorizations []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if r.URL.Path != "/inference-profiles" {
+			http.NotFound(w, r)
+			return
+		}
+		page := map[string]interface{}{
+			"inferenceProfileSummaries": []InferenceProfile{{ID: "us.anthropic.claude-3-5-sonnet-20241022-v2:0", Name: "US Claude 3.5 Sonnet", Type: "SYSTEM_DEFINED", Status: "ACTIVE"}},
+			"nextToken":                 "page-2",
+		}
+		if r.URL.Query().Get("nextToken") == "page-2" {
+			page = map[string]interface{}{
+				"inferenceProfileSummaries": []InferenceProfile{{ID: "eu.anthropic.claude-3-haiku-20240307-v1:0", Name: "EU Claude 3 Haiku", Type: "SYSTEM_DEFINED", Status: "ACTIVE"}},
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), authorizations...)
+	}
+}
+
+func TestListInferenceProfiles(t *testing.T) {
+	srv, authorizations := newMockBedrockControlPlane(t)
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretKey: "secret"}
+	profiles, err := listInferenceProfiles(context.Background(), srv.Client(), srv.URL, "us-east-1", creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || profiles[0].ID != "us.anthropic.claude-3-5-sonnet-20241022-v2:0" || profiles[1].ID != "eu.anthropic.claude-3-haiku-20240307-v1:0" {
+		t.Errorf("profiles = %+v", profiles)
+	}
+	for _, auth := range authorizations() {
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/us-east-1/bedrock/aws4_request") {
+			t.Errorf("request signed with %q", auth)
+		}
+	}
+}
+
+func TestRunInferenceProfileList(t *testing.T) {
+	srv, authorizations := newMockBedrockControlPlane(t)
+	// The subcommand sets the shared flags; restore them afterwards
+	setFlag(t, awsRegion, *awsRegion)
+	setFlag(t, awsAccessKeyID, *awsAccessKeyID)
+	setFlag(t, awsSecretKey, *awsSecretKey)
+
+	err := runInferenceProfileList([]string{"-endpoint-template", srv.URL, "-aws-region", "eu-west-1", "-aws-access-key-id", "AKIDEXAMPLE", "-aws-secret-key", "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := authorizations()
+	if len(got) != 2 || !strings.Contains(got[0], "/eu-west-1/bedrock/") {
+		t.Errorf("requests signed with %q, want two eu-west-1 requests", got)
+	}
+}
+
+func TestNewSubcommandFlagSet(t *testing.T) {
+	setFlag(t, modelName, *modelName)
+	fs := newSubcommandFlagSet("test-subcommand")
+	own := fs.Int("own-flag", 0, "")
+	if err := fs.Parse([]string{"-model-name", "shared-model", "-own-flag", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if *modelName != "shared-model" || *own != 3 {
+		t.Errorf("-model-name = %q, -own-flag = %d", *modelName, *own)
+	}
+}
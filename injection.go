@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// injectionRedactedResult replaces tool results that look like prompt injection attempts
+const injectionRedactedResult = "[Tool result redacted: potential prompt injection detected]"
+
+// injectionPatterns matches common phrases used to hijack the model through tool output
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,20}\b(all|any|the|previous|prior|above|earlier)\b.{0,20}\b(instructions?|prompts?|rules|directions)\b`),
+	regexp.MustCompile(`(?i)\bnew\s+instructions?\b`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+now\b`),
+	regexp.MustCompile(`(?i)\b(reveal|print|show)\b.{0,20}\bsystem\s+prompt\b`),
+}
+
+// loadInjectionPatterns appends case-insensitive patterns from a file, one regex per line.
+// Blank lines and lines starting with # are skipped.
+func loadInjectionPatterns(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid pattern: %w", path, lineNum, err)
+		}
+		injectionPatterns = append(injectionPatterns, re)
+	}
+	return scanner.Err()
+}
+
+// detectInjection reports whether the tool result matches a known injection pattern
+// and returns the pattern that matched
+func detectInjection(toolResult string) (bool, string) {
+	for _, re := range injectionPatterns {
+		if re.MatchString(toolResult) {
+			return true, re.String()
+		}
+	}
+	return false, ""
+}
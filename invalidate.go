@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+// runCacheInvalidate implements the cache-invalidate subcommand
+func runCacheInvalidate(args []string) error {
+	fs := flag.NewFlagSet("cache-invalidate", flag.ExitOnError)
+	dir := fs.String("cache-dir", defaultResponseCacheDir, "Response cache directory")
+	model := fs.String("model", "", "Only invalidate entries for this model (default any model)")
+	pattern := fs.String("pattern", "", "Glob matched against the first user message of each entry (default any question)")
+	fs.Parse(args)
+
+	if *model == "" && *pattern == "" {
+		return errors.New("cache-invalidate: set -model, -pattern or both")
+	}
+	invalidated, err := InvalidateCache(&ResponseStore{Dir: *dir}, *model, *pattern)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Invalidated %d entries\n", invalidated)
+	return nil
+}
+
+// InvalidateCache deletes the entries for model (any model when empty) whose first user
+// message matches the filepath.Match glob pattern (any message when empty), returning
+// how many were deleted
+func InvalidateCache(store *ResponseStore, model, pattern string) (int, error) {
+	if pattern != "" {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	keys, err := store.List()
+	if err != nil {
+		return 0, err
+	}
+	invalidated := 0
+	for _, key := range keys {
+		entry, err := store.Load(key)
+		if err != nil {
+			return invalidated, err
+		}
+		if model != "" && entry.Model != model {
+			continue
+		}
+		if pattern != "" {
+			question, err := entry.firstUserMessage()
+			if err != nil {
+				continue
+			}
+			if matched, _ := filepath.Match(pattern, question); !matched {
+				continue
+			}
+		}
+		if err := store.Delete(key); err != nil {
+			return invalidated, fmt.Errorf("deleting cache entry %s: %w", key, err)
+		}
+		invalidated++
+	}
+	return invalidated, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+// questionParams is a request for model asking question
+func questionParams(model, question string) openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are a helpful assistant."),
+			openai.UserMessage(question),
+		}),
+		Model: openai.F(model),
+	}
+}
+
+func TestInvalidateCache(t *testing.T) {
+	store := &ResponseStore{Dir: t.TempDir()}
+	entries := []openai.ChatCompletionNewParams{
+		questionParams("gpt-4o", "What is the weather in New York?"),
+		questionParams("gpt-4o", "What is the weather in Paris?"),
+		questionParams("gpt-4o", "What time is it in Tokyo?"),
+		questionParams("gpt-4o-mini", "What is the weather in Berlin?"),
+		questionParams("gpt-4o", "Tell me a joke"),
+	}
+	resp := testCompletion(t, "cached")
+	for _, params := range entries {
+		key, err := responseCacheKey(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Put(key, params, &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := InvalidateCache(store, "gpt-4o", "*weather*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("invalidated %d entries, want 2", n)
+	}
+	keys, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("%d entries left, want 3", len(keys))
+	}
+	for _, params := range entries[2:] {
+		key, _ := responseCacheKey(params)
+		if _, ok := store.Get(key); !ok {
+			t.Errorf("entry for %q was deleted", messageText(params.Messages.Value[1]))
+		}
+	}
+
+	if _, err := InvalidateCache(store, "", "[weather"); err == nil {
+		t.Error("malformed pattern accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonArrayFilterPattern matches the supported filter form: .field == "value" or .field != "value"
+var jsonArrayFilterPattern = regexp.MustCompile(`^\.([A-Za-z_][A-Za-z0-9_]*)\s*(==|!=)\s*("(?:[^"\\]|\\.)*")$`)
+
+// filterJSONArray decodes data as a JSON array and returns the elements matching filter.
+// An empty filter keeps every element; otherwise it compares a top-level string field.
+func filterJSONArray(data []byte, filter string) ([]json.RawMessage, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, fmt.Errorf("response is not a JSON array: %w", err)
+	}
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return elements, nil
+	}
+	m := jsonArrayFilterPattern.FindStringSubmatch(filter)
+	if m == nil {
+		return nil, fmt.Errorf(`unsupported filter %q, want .field == "value"`, filter)
+	}
+	field, op := m[1], m[2]
+	want, err := strconv.Unquote(m[3])
+	if err != nil {
+		return nil, fmt.Errorf("filter value %s: %w", m[3], err)
+	}
+
+	var matched []json.RawMessage
+	for _, element := range elements {
+		var obj map[string]interface{}
+		json.Unmarshal(element, &obj)
+		value, ok := obj[field].(string)
+		if (op == "==") == (ok && value == want) {
+			matched = append(matched, element)
+		}
+	}
+	return matched, nil
+}
+
+// filterJSONKeys keeps only the comma-separated top-level keys of a JSON object; other
+// values are returned unchanged
+func filterJSONKeys(data []byte, keys string) []byte {
+	if strings.TrimSpace(keys) == "" {
+		return data
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return data
+	}
+	kept := make(map[string]json.RawMessage)
+	for _, key := range strings.Split(keys, ",") {
+		if value, ok := obj[strings.TrimSpace(key)]; ok {
+			kept[strings.TrimSpace(key)] = value
+		}
+	}
+	out, err := json.Marshal(kept)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// stripCodeFence removes a surrounding ``` or ```json fence from a model response
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "```"), "```")
+	if i := strings.IndexByte(text, '\n'); i >= 0 && !strings.ContainsAny(text[:i], "[{") {
+		text = text[i+1:]
+	}
+	return strings.TrimSpace(text)
+}
+
+// extractJSONArrayResponse handles -json-extract-array: when the response is a JSON array,
+// each element matching -json-array-filter is post-processed and key-filtered on its own
+// and returned as one line. ok is false when the flag is off or the response is not an array.
+func extractJSONArrayResponse(text string) (lines []string, ok bool) {
+	if !*jsonExtractArray {
+		return nil, false
+	}
+	elements, err := filterJSONArray([]byte(stripCodeFence(text)), *jsonArrayFilter)
+	if err != nil {
+		log.Printf("Warning: -json-extract-array: %v", err)
+		return nil, false
+	}
+
+	lines = make([]string, 0, len(elements))
+	for _, element := range elements {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, element); err != nil {
+			log.Printf("Warning: -json-extract-array: %v", err)
+			continue
+		}
+		line := compact.String()
+		if *responsePostprocess != "" {
+			processed, err := postprocessResponse(*responsePostprocess, line, *responsePostprocessTimeout)
+			if err != nil {
+				log.Printf("Warning: response postprocess failed, using original element: %v", err)
+			} else {
+				line = strings.TrimSpace(processed)
+			}
+		}
+		lines = append(lines, string(filterJSONKeys([]byte(line), *jsonKeyFilter)))
+	}
+	return lines, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testWarningsArray = "```json\n" + `[
+  {"type": "warning", "city": "Oslo", "detail": "wind"},
+  {"type": "info", "city": "Paris", "detail": "sun"},
+  {"type": "warning", "city": "Rome", "detail": "heat"},
+  {"type": "info", "city": "Berlin", "detail": "rain"},
+  {"kind": "warning", "city": "Madrid"}
+]` + "\n```"
+
+func TestExtractJSONArrayResponse(t *testing.T) {
+	setFlag(t, jsonExtractArray, true)
+	setFlag(t, responsePostprocess, "")
+
+	tests := []struct {
+		filter string
+		keys   string
+		want   []string
+	}{
+		{`.type == "warning"`, "", []string{
+			`{"type":"warning","city":"Oslo","detail":"wind"}`,
+			`{"type":"warning","city":"Rome","detail":"heat"}`,
+		}},
+		{`.type == "warning"`, "city, type", []string{
+			`{"city":"Oslo","type":"warning"}`,
+			`{"city":"Rome","type":"warning"}`,
+		}},
+		{`.type != "warning"`, "city", []string{
+			`{"city":"Paris"}`,
+			`{"city":"Berlin"}`,
+			`{"city":"Madrid"}`,
+		}},
+	}
+	for _, tt := range tests {
+		setFlag(t, jsonArrayFilter, tt.filter)
+		setFlag(t, jsonKeyFilter, tt.keys)
+		got, ok := extractJSONArrayResponse(testWarningsArray)
+		if !ok {
+			t.Fatalf("filter %s: response not handled as an array", tt.filter)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %s keys %q:\n got %q\nwant %q", tt.filter, tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestExtractJSONArrayResponseNotArray(t *testing.T) {
+	setFlag(t, jsonExtractArray, true)
+	setFlag(t, jsonArrayFilter, "")
+	if _, ok := extractJSONArrayResponse(`{"type": "warning"}`); ok {
+		t.Error("an object was handled as an array")
+	}
+	setFlag(t, jsonExtractArray, false)
+	if _, ok := extractJSONArrayResponse(testWarningsArray); ok {
+		t.Error("array handled with -json-extract-array off")
+	}
+}
+
+func TestFilterJSONArrayInvalidFilter(t *testing.T) {
+	for _, filter := range []string{"type == warning", `.type > "a"`, `.a.b == "x"`} {
+		if _, err := filterJSONArray([]byte("[]"), filter); err == nil {
+			t.Errorf("filter %q accepted", filter)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// serviceAccountTokenPath is where Kubernetes mounts the pod's service account token
+var serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// serviceAccountToken is the token sent as a Bearer token on every gateway request when
+// -gateway-url-from-service-account is set, read once by newClient
+var serviceAccountToken string
+
+// resolveKubernetesServiceURL builds the service URL from the <NAME>_SERVICE_HOST and
+// <NAME>_SERVICE_PORT variables Kubernetes injects into pods
+func resolveKubernetesServiceURL(serviceName string) (string, error) {
+	prefix := strings.ToUpper(strings.ReplaceAll(serviceName, "-", "_"))
+	host := os.Getenv(prefix + "_SERVICE_HOST")
+	port := os.Getenv(prefix + "_SERVICE_PORT")
+	if host == "" || port == "" {
+		return "", fmt.Errorf("%s_SERVICE_HOST or %s_SERVICE_PORT is not set", prefix, prefix)
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
+
+// readServiceAccountToken returns the mounted service account token
+func readServiceAccountToken() (string, error) {
+	data, err := os.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("service account token %s is empty", serviceAccountTokenPath)
+	}
+	return token, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestResolveKubernetesServiceURL(t *testing.T) {
+	t.Setenv("AI_GATEWAY_SERVICE_HOST", "10.0.0.7")
+	t.Setenv("AI_GATEWAY_SERVICE_PORT", "8080")
+	got, err := resolveKubernetesServiceURL("ai-gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "http://10.0.0.7:8080" {
+		t.Errorf("resolveKubernetesServiceURL = %q", got)
+	}
+	if _, err := resolveKubernetesServiceURL("missing-service"); err == nil {
+		t.Error("service without env vars resolved")
+	}
+}
+
+func TestGatewayURLFromServiceAccount(t *testing.T) {
+	authorizations := make(chan string, 1)
+	mock := NewMockGatewayServer(0, []openai.ChatCompletion{testCompletion(t, "Sunny")}).Handler()
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations <- r.Header.Get("Authorization")
+		mock.ServeHTTP(w, r)
+	}))
+	defer gateway.Close()
+	u, err := url.Parse(gateway.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEMO_GATEWAY_SERVICE_HOST", host)
+	t.Setenv("DEMO_GATEWAY_SERVICE_PORT", port)
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, &serviceAccountTokenPath, tokenPath)
+	setFlag(t, &serviceAccountToken, "")
+	setFlag(t, gatewayURLFromServiceAccount, true)
+	setFlag(t, gatewayK8sServiceName, "demo-gateway")
+	setFlag(t, useAIGateway, true)
+	setFlag(t, aiGatewayURL, "http://127.0.0.1:1")
+
+	client, _, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}),
+		Model:    openai.F("test-model"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorization := <-authorizations; authorization != "Bearer sa-token" {
+		t.Errorf("Authorization = %q, want the service account token", authorization)
+	}
+}
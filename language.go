@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageSamples are short passages of common words used to build the trigram profiles
+var languageSamples = map[string]string{
+	"en": "the weather is sunny and the temperature is warm today. it will be cloudy with a chance of rain in the evening and there is wind from the north. this is what we have for you and that was the forecast",
+	"fr": "le temps est ensoleillé et la température est chaude aujourd'hui. il y aura des nuages avec une chance de pluie dans la soirée et le vent vient du nord. c'est ce que nous avons pour vous et les prévisions",
+	"de": "das wetter ist sonnig und die temperatur ist heute warm. es wird bewölkt mit einer chance auf regen am abend und der wind kommt aus dem norden. das ist die vorhersage für sie und nicht mehr",
+	"es": "el tiempo está soleado y la temperatura es cálida hoy. estará nublado con una probabilidad de lluvia por la noche y el viento viene del norte. esto es lo que tenemos para usted y los pronósticos del día",
+}
+
+// languageProfiles maps each language to the relative frequency of its trigrams
+var languageProfiles = buildLanguageProfiles(languageSamples)
+
+// buildLanguageProfiles computes normalized trigram frequencies for each sample
+func buildLanguageProfiles(samples map[string]string) map[string]map[string]float64 {
+	profiles := make(map[string]map[string]float64, len(samples))
+	for lang, text := range samples {
+		counts := trigrams(text)
+		total := 0
+		for _, n := range counts {
+			total += n
+		}
+		profile := make(map[string]float64, len(counts))
+		for t, n := range counts {
+			profile[t] = float64(n) / float64(total)
+		}
+		profiles[lang] = profile
+	}
+	return profiles
+}
+
+// trigrams counts the letter trigrams of text, padding each word with spaces
+func trigrams(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+	return counts
+}
+
+// undeterminedLanguage is the ISO 639 code detectLanguage returns when it cannot tell the
+// language, for instance for numbers or words none of the profiles know
+const undeterminedLanguage = "und"
+
+// minLanguageLetters is the fewest letters detectLanguage classifies; shorter text such as
+// "25°C" gives too little evidence
+const minLanguageLetters = 4
+
+// detectLanguage returns the ISO 639-1 code of the closest supported language
+// (en, fr, de, es, zh), or undeterminedLanguage when text has too few letters or shares
+// no trigrams with any profile
+func detectLanguage(text string) string {
+	letters, han := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.Is(unicode.Han, r) {
+				han++
+			}
+		}
+	}
+	if han > 0 && han*2 >= letters {
+		return "zh"
+	}
+	if letters < minLanguageLetters {
+		return undeterminedLanguage
+	}
+
+	best, bestScore := undeterminedLanguage, 0.0
+	counts := trigrams(text)
+	for _, lang := range []string{"en", "fr", "de", "es"} {
+		score := 0.0
+		for t, n := range counts {
+			score += float64(n) * languageProfiles[lang][t]
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
+
+// languageNote returns the translation note for a tool result in another language,
+// or an empty string when the result matches the expected language or its language
+// cannot be determined
+func languageNote(result, expected string) string {
+	detected := detectLanguage(result)
+	if detected == undeterminedLanguage || detected == expected {
+		return ""
+	}
+	return "[Note: tool result is in " + detected + ". Translate before using.]"
+}
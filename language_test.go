@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The weather is sunny with a light wind from the north.", "en"},
+		{"Le temps est ensoleillé avec un léger vent du nord.", "fr"},
+		{"Das Wetter ist sonnig mit leichtem Wind aus dem Norden.", "de"},
+		{"El tiempo está soleado con un viento ligero del norte.", "es"},
+		{"今天天气晴朗，北风微弱。", "zh"},
+		{"25°C, 1013", undeterminedLanguage},
+		{"xqz jjw", undeterminedLanguage},
+		{"", undeterminedLanguage},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageNote(t *testing.T) {
+	if note := languageNote("Le temps est ensoleillé avec un léger vent du nord.", "en"); note != "[Note: tool result is in fr. Translate before using.]" {
+		t.Errorf("French result note = %q", note)
+	}
+	for _, result := range []string{"The weather is sunny.", "xqz jjw", "25°C"} {
+		if note := languageNote(result, "en"); note != "" {
+			t.Errorf("languageNote(%q) = %q, want no note", result, note)
+		}
+	}
+}
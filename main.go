@@ -1,14 +1,20 @@
 package main
+
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	openai "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
+
 var (
 	useAIGateway    = flag.Bool("use-ai-gateway", true, "Use AI Gateway instead of direct Bedrock")
 	aiGatewayURL    = flag.String("ai-gateway-url", "http://localhost:8080", "AI Gateway URL")
@@ -17,11 +23,486 @@ var (
 	awsSessionToken = flag.String("aws-session-token", "", "AWS Session Token (optional)")
 	modelName       = flag.String("model-name", "eu.anthropic.claude-3-5-sonnet-20240620-v1:0", "Bedrock model name")
 	toolURL         = flag.String("tool-url", "", "External tool URL for weather service")
+
+	promptInjectionDetection = flag.Bool("prompt-injection-detection", false, "Redact tool results that look like prompt injection attempts")
+	injectionPatternsFile    = flag.String("injection-detection-patterns-file", "", "File with additional prompt injection regex patterns, one per line")
+
+	responsePostprocess        = flag.String("response-postprocess", "", "Executable that transforms the final response text (stdin to stdout)")
+	responsePostprocessTimeout = flag.Duration("response-postprocess-timeout", 5*time.Second, "Timeout for the response postprocess executable")
+
+	sweepTemperatures = flag.String("parameter-sweep-temperatures", "", "Comma-separated temperatures to run the same question with (e.g. 0.0,0.5,1.0)")
+	concurrency       = flag.Int("concurrency", 1, "Number of requests to run in parallel")
+
+	systemPrompt   = flag.String("system-prompt", "", "System prompt to send before the question")
+	noSystemPrompt = flag.Bool("no-system-prompt", false, "Send requests without any system message")
+
+	awsCredentialsFile = flag.String("aws-credentials-file", "", "AWS shared credentials or config file to load keys from")
+	awsProfile         = flag.String("aws-profile", defaultAWSProfile(), "AWS profile to read from -aws-credentials-file")
+
+	sessionFile     = flag.String("session-file", "", "Session file to continue the conversation from and save it to")
+	compressSession = flag.Bool("compress-session", false, "Gzip-compress the saved session file (appends .gz to -session-file)")
+
+	inputFromClipboard = flag.Bool("input-from-clipboard", false, "Read the question from the system clipboard")
+	outputToClipboard  = flag.Bool("output-to-clipboard", false, "Copy the final response to the system clipboard")
+
+	maxFunctionArguments = flag.Int("max-function-arguments", 0, "Maximum number of arguments per tool schema (0 = unlimited)")
+
+	jsonOutput            = flag.Bool("json-output", false, "Print machine-readable JSON output")
+	outputOnlyToolResults = flag.Bool("output-only-tool-results", false, "Print the tool results and exit without sending the final request")
+
+	requestTimeout   = flag.Duration("timeout", 0, "Overall timeout for the conversation including tool dispatch (0 = no timeout)")
+	gatewayTimeoutMs = flag.Int("gateway-timeout-ms", 0, "Timeout in milliseconds for each AI Gateway call (0 = use overall timeout)")
+
+	verbose         = flag.Bool("verbose", false, "Print extra diagnostics to stderr")
+	questionRewrite = flag.Bool("question-rewrite", false, "Ask the model to rewrite the question to be clearer before answering it")
+	rewriteModel    = flag.String("rewrite-model", "", "Model used for -question-rewrite (defaults to -model-name)")
+
+	gatewayRequestIDHeader = flag.String("gateway-request-id-header", "X-Request-ID", "Header carrying the request ID on AI Gateway calls")
+	gatewayRequestIDEnv    = flag.String("gateway-request-id-env", "", "Environment variable holding an upstream request ID to propagate (e.g. REQUEST_ID)")
+	gatewayRequestID       = flag.String("gateway-request-id", "", "Explicit request ID to send (a new UUID is generated per request when unset)")
+
+	modelCapabilitiesCheck = flag.Bool("model-capabilities-check", false, "Check that the model supports tool calling before sending requests")
+
+	toolDedupWindow = flag.Int("tool-dedup-window", 0, "Reuse results of identical tool calls among the last N unique calls in the session (0 = disabled)")
+
+	stream            = flag.Bool("stream", false, "Stream responses from the model")
+	streamingProgress = flag.Bool("streaming-progress", false, "Show a tokens-per-second progress line while streaming (requires -stream)")
+
+	toolAuthHeadersJSON = flag.String("tool-auth-headers", "", `JSON map of tool name to Authorization header for tool HTTP calls, e.g. {"get_weather": "Bearer <token>"}`)
+
+	ragCorpusDir = flag.String("rag-corpus-dir", "", "Directory of .txt files to retrieve context from before each request")
+
+	outputDiffFromLast = flag.Bool("output-diff-from-last", false, "Print only what changed since the previous response saved in -last-response-file")
+	lastResponseFile   = flag.String("last-response-file", ".last-response.txt", "File the previous response is read from and saved to for -output-diff-from-last")
+
+	awsRegion               = flag.String("aws-region", defaultAWSRegion(), "AWS region for direct Bedrock requests")
+	awsBedrockRegions       = flag.String("aws-bedrock-regions", "", "Comma-separated Bedrock regions to fail over between (overrides -aws-region)")
+	bedrockEndpointTemplate = flag.String("bedrock-endpoint-template", "https://bedrock-runtime.{region}.amazonaws.com/openai/v1/", "Bedrock OpenAI-compatible endpoint, {region} is replaced with the region")
+
+	parallelTools    = flag.Bool("parallel-tools", false, "Dispatch the tool calls of a response concurrently")
+	enforceToolOrder = flag.Bool("enforce-tool-order", false, "Always dispatch tool calls sequentially in the order the model listed them, even with -parallel-tools")
+
+	gatewayURLFromServiceAccount = flag.Bool("gateway-url-from-service-account", false, "Discover the AI Gateway from Kubernetes service env vars and authenticate with the pod's service account token")
+	gatewayK8sServiceName        = flag.String("gateway-k8s-service-name", "ai-gateway", "Kubernetes service name used by -gateway-url-from-service-account")
+
+	toolResultLanguageDetect = flag.Bool("tool-result-language-detect", false, "Flag tool results whose detected language differs from -expected-language")
+	expectedLanguage         = flag.String("expected-language", "en", "Language code tool results are expected in (en, fr, de, es, zh)")
+
+	requestDeduplication = flag.Bool("request-deduplication", false, "Share the response of identical completion requests that are in flight at the same time")
+
+	exitCodeFromResponse = flag.Bool("exit-code-from-response", false, "Set the exit code from the final response using -success-pattern and -failure-pattern")
+	successPattern       = flag.String("success-pattern", "", "Regex that marks the final response as a success (exit 0)")
+	failurePattern       = flag.String("failure-pattern", "", "Regex that marks the final response as a failure (exit 1); wins over -success-pattern")
+
+	gatewayWebsocket = flag.Bool("gateway-websocket", false, "Stream completions over the AI Gateway's WebSocket endpoint (ws://<gateway>/v1/chat); implies -stream")
+
+	replMode          = flag.Bool("repl", false, "Ask questions interactively, continuing the conversation across turns")
+	promptHistory     = flag.Bool("prompt-history", false, "Keep a history of REPL questions, navigable with the up and down arrows")
+	promptHistoryFile = flag.String("prompt-history-file", ".prompt-history", "File used to persist -prompt-history")
+
+	conversationTitle     = flag.String("conversation-title", "", "Title stored in the session metadata")
+	autoTitleFromResponse = flag.Bool("auto-title-from-response", false, "Title the session after the first sentence of its first response when -conversation-title is not set")
+
+	toolVerificationModel = flag.String("tool-verification-model", "", "Model asked to check each tool result for plausibility; rejected results are marked unverified")
+
+	contextModelTiersJSON = flag.String("context-model-tiers", "", `JSON list of {"max_tokens":N,"model":"..."} tiers, cheapest first; each request uses the first tier that fits the history`)
+
+	responseCacheDir = flag.String("response-cache-dir", "", "Cache non-streamed completions in this directory and answer repeated requests from it (see cache-invalidate)")
+
+	auditLogPath            = flag.String("audit-log", "", "Append a JSON line per completed turn to this file")
+	auditLogRotateOnStartup = flag.Bool("audit-log-rotate-on-startup", false, "Rename an existing -audit-log to <base>.<timestamp>.log before opening it")
+	auditLogMaxFiles        = flag.Int("audit-log-max-files", 10, "Number of rotated audit logs to keep; 0 keeps all")
+
+	toolFallbackChain = flag.String("tool-fallback-chain", "", `JSON object of tool name to URLs tried in order, e.g. {"get_weather":["http://primary/weather","http://backup/weather"]}`)
+
+	functionCallingFallback = flag.Bool("function-calling-fallback", false, `Treat get_weather(location="...") style calls in a plain-text response as tool calls`)
+
+	toolHTTPMethodsJSON = flag.String("tool-http-methods", "", `JSON object of tool name to HTTP method (GET or POST), e.g. {"get_weather":"POST"}`)
+
+	conversationLock = flag.Bool("conversation-lock", false, "Lock the session file (via <session-file>.lock) while it is read or written")
+	lockTimeout      = flag.Duration("lock-timeout", 5*time.Second, "How long to wait for -conversation-lock before failing")
+
+	toolSchemaValidationMode = flag.String("tool-schema-validation-mode", string(ValidationLenient), "How tool arguments are checked against the tool schema: strict, lenient or off")
+	toolSchemaCoerceTypes    = flag.Bool("tool-schema-coerce-types", false, `Convert string tool arguments such as "25" to the number or boolean the schema expects`)
+
+	gatewayCABundle         = flag.String("gateway-ca-bundle", "", "PEM file of CA certificates to trust for requests, in addition to the system pool")
+	gatewayCABundleOverride = flag.Bool("gateway-ca-bundle-override", false, "Trust only the -gateway-ca-bundle certificates, not the system pool")
+
+	maxOutputChars             = flag.Int("max-output-chars", 0, "Truncate the final response to this many characters; also the length above which the summary strategy summarizes (0 disables)")
+	responseTruncationStrategy = flag.String("response-truncation-strategy", "chars", "How to shorten the final response: chars, sentences, paragraphs or summary")
+	truncateSentences          = flag.Int("truncate-sentences", 0, "Sentences kept by the sentences truncation strategy")
+	truncateParagraphs         = flag.Int("truncate-paragraphs", 0, "Paragraphs kept by the paragraphs truncation strategy")
+
+	awsBedrockInferenceProfile = flag.String("aws-bedrock-inference-profile", "", "Bedrock inference profile ID or ARN (e.g. us.anthropic.claude-3-5-sonnet-20241022-v2:0) sent as the model instead of -model-name")
+
+	contextInjectionStrategy = flag.String("context-injection-strategy", injectSystemPrepend, "Where injected context goes: system-prepend, system-append, user-prepend, separate-user or separate-assistant (system strategies use user-prepend with -no-system-prompt)")
+
+	gatewayConnectionTest = flag.Bool("gateway-connection-test", false, "Ping <ai-gateway-url>/health before the request and report the round-trip latency")
+	gatewayLatencyWarnMs  = flag.Int("gateway-latency-warn-ms", 500, "Warn when the average -gateway-connection-test latency exceeds this many milliseconds")
+
+	toolDescriptionEnhancement = flag.Bool("tool-description-enhancement", false, "Ask the model to rewrite each tool description at startup")
+	enhanceDescriptionsCache   = flag.String("enhance-descriptions-cache", "", "File that keeps -tool-description-enhancement results between runs")
+
+	jsonExtractArray = flag.Bool("json-extract-array", false, "When the final response is a JSON array, post-process and print each element on its own line")
+	jsonArrayFilter  = flag.String("json-array-filter", "", `Keep only -json-extract-array elements matching a condition like .type == "warning"`)
+	jsonKeyFilter    = flag.String("json-key-filter", "", "Comma-separated top-level keys kept in each -json-extract-array element")
+
+	costTrackingFile = flag.String("cost-tracking-file", "", "JSON file accumulating token usage and estimated cost per model across runs (see cost-report)")
+
+	systemPromptTemplate = flag.Bool("system-prompt-template", false, "Render -system-prompt as a Go text/template with .Env, .Now, .Hostname, .ConversationID and .TurnNumber")
+
+	validateOpenAIParams = flag.Bool("validate-openai-params", false, "Warn about known problematic parameter combinations before each request")
+
+	messageContentHash = flag.Bool("message-content-hash", false, "Record each message with its SHA-256 hash in the audit log (check with audit-verify)")
+
+	gatewayTimeoutBudgetAware = flag.Bool("gateway-timeout-budget-aware", false, "Cap each gateway call to 80% of what remains of -timeout")
+
+	toolParallelLimitPerType = flag.String("tool-parallel-limit-per-type", "", "JSON object capping concurrent -parallel-tools calls per tool, e.g. {\"get_weather\": 2}")
 )
+
 const question = "What is the weather in New York City?"
 
+// subcommands maps subcommand names to their entry points; each parses its own flags
+var subcommands = map[string]func(args []string) error{
+	"session-prune":          runSessionPrune,
+	"replay":                 runReplay,
+	"stats":                  runStats,
+	"cache-invalidate":       runCacheInvalidate,
+	"preload-test":           runPreloadTest,
+	"mock-gateway":           runMockGateway,
+	"inference-profile-list": runInferenceProfileList,
+	"cost-report":            runCostReport,
+	"audit-verify":           runAuditVerify,
+}
+
+// newSubcommandFlagSet returns the flag set for subcommand name, accepting the flags of
+// the main command too so that subcommands reach the same backend
+func newSubcommandFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	return fs
+}
+
+// splitCommaList splits a comma-separated flag value, trimming spaces and dropping empty items
+func splitCommaList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
 	flag.Parse()
+	os.Exit(run())
+}
+
+// run executes a single conversation, or the REPL, and returns the process exit code.
+// It returns rather than exiting so that deferred cleanup such as closing the audit log
+// runs first.
+func run() int {
+	if err := checkSystemPromptFlags(*noSystemPrompt, *systemPrompt); err != nil {
+		log.Fatal(err)
+	}
+	if *systemPromptTemplate {
+		if _, err := renderSystemPromptTemplate(*systemPrompt, newPromptData(""), 1); err != nil {
+			log.Fatalf("Invalid -system-prompt: %v", err)
+		}
+	}
+	if *gatewayWebsocket {
+		if !*useAIGateway {
+			log.Fatal("-gateway-websocket requires -use-ai-gateway")
+		}
+		*stream = true
+	}
+	if *gatewayTimeoutBudgetAware && *requestTimeout <= 0 {
+		log.Fatal("-gateway-timeout-budget-aware requires -timeout")
+	}
+	if *promptHistory && !*replMode {
+		log.Fatal("-prompt-history requires -repl")
+	}
+	if *streamingProgress && !*stream {
+		log.Fatal("-streaming-progress requires -stream")
+	}
+	if *injectionPatternsFile != "" {
+		if err := loadInjectionPatterns(*injectionPatternsFile); err != nil {
+			log.Fatalf("Error loading injection detection patterns: %v", err)
+		}
+	}
+	if _, err := parseValidationMode(*toolSchemaValidationMode); err != nil {
+		log.Fatalf("Invalid -tool-schema-validation-mode: %v", err)
+	}
+	if *gatewayCABundleOverride && *gatewayCABundle == "" {
+		log.Fatal("-gateway-ca-bundle-override requires -gateway-ca-bundle")
+	}
+	if _, err := filterJSONArray([]byte("[]"), *jsonArrayFilter); err != nil {
+		log.Fatalf("Invalid -json-array-filter: %v", err)
+	}
+	if err := validateInjectionStrategy(*contextInjectionStrategy); err != nil {
+		log.Fatalf("Invalid -context-injection-strategy: %v", err)
+	}
+	if _, err := truncationStrategy(*responseTruncationStrategy); err != nil {
+		log.Fatalf("Invalid -response-truncation-strategy: %v", err)
+	}
+	toolParallelLimits, err := parseToolParallelLimits(*toolParallelLimitPerType)
+	if err != nil {
+		log.Fatalf("Invalid -tool-parallel-limit-per-type: %v", err)
+	}
+	if *exitCodeFromResponse {
+		if err := validateExitCodePatterns(*successPattern, *failurePattern); err != nil {
+			log.Fatalf("Invalid exit code pattern: %v", err)
+		}
+	}
+	if *responsePostprocess != "" {
+		if err := validateExecutable(*responsePostprocess); err != nil {
+			log.Fatalf("Invalid response postprocess executable: %v", err)
+		}
+	}
+
+	if err := applyAWSCredentialsFile(); err != nil {
+		log.Fatalf("Error loading AWS credentials: %v", err)
+	}
+
+	userQuestion := question
+	if *inputFromClipboard {
+		text, err := readFromClipboard()
+		if err != nil {
+			log.Fatalf("Error reading question from clipboard: %v", err)
+		}
+		userQuestion = text
+	}
+
+	headers, err := parseToolAuthHeaders(*toolAuthHeadersJSON)
+	if err != nil {
+		log.Fatalf("Invalid -tool-auth-headers: %v", err)
+	}
+	toolAuthHeaders = headers
+	if toolHTTPMethods, err = parseToolHTTPMethods(*toolHTTPMethodsJSON); err != nil {
+		log.Fatalf("Invalid -tool-http-methods: %v", err)
+	}
+
+	if *ragCorpusDir != "" {
+		docs, err := loadRAGCorpus(*ragCorpusDir)
+		if err != nil {
+			log.Fatalf("Error loading RAG corpus: %v", err)
+		}
+		ragIndex = &TFIDFIndex{}
+		if err := ragIndex.Build(docs); err != nil {
+			log.Fatalf("Error indexing RAG corpus %s: %v", *ragCorpusDir, err)
+		}
+		log.Printf("Indexed %d chunks from %s", len(docs), *ragCorpusDir)
+	}
+
+	if contextModelTiers, err = parseModelTiers(*contextModelTiersJSON); err != nil {
+		log.Fatalf("Invalid -context-model-tiers: %v", err)
+	}
+	if err := checkContextModelTierFlags(contextModelTiers, *awsBedrockInferenceProfile); err != nil {
+		log.Fatal(err)
+	}
+
+	if *auditLogPath != "" {
+		if *auditLogRotateOnStartup {
+			if err := rotateLogFile(*auditLogPath); err != nil {
+				log.Fatalf("Error rotating audit log: %v", err)
+			}
+			if err := pruneRotatedLogs(*auditLogPath, *auditLogMaxFiles); err != nil {
+				log.Printf("Warning: failed to prune rotated audit logs: %v", err)
+			}
+		}
+		if auditLog, err = openAuditLog(*auditLogPath); err != nil {
+			log.Fatalf("Error opening audit log: %v", err)
+		}
+		defer auditLog.Close()
+	}
+	if *costTrackingFile != "" {
+		costTracker = &CostTracker{Path: *costTrackingFile}
+	}
+	if *responseCacheDir != "" {
+		responseStore = &ResponseStore{Dir: *responseCacheDir}
+	}
+
+	registry, err := newDefaultToolRegistry(*maxFunctionArguments)
+	if err != nil {
+		log.Printf("Error registering tools: %v", err)
+		return 1
+	}
+	if err := applyToolFallbackChain(registry, *toolFallbackChain); err != nil {
+		log.Printf("Invalid -tool-fallback-chain: %v", err)
+		return 1
+	}
+
+	client, httpClient, err := newClient()
+	if err != nil {
+		log.Printf("Error building HTTP client: %v", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	timeoutBudgetStart = time.Now()
+	if *requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *requestTimeout)
+		defer cancel()
+	}
+
+	truncation = TruncationConfig{
+		MaxChars:   *maxOutputChars,
+		Sentences:  *truncateSentences,
+		Paragraphs: *truncateParagraphs,
+		Summarize: func(text string) (string, error) {
+			return summarizeText(ctx, client, *modelName, text)
+		},
+	}
+	if *toolDescriptionEnhancement {
+		if err := enhanceToolDescriptions(ctx, client, *modelName, registry); err != nil {
+			log.Printf("Warning: tool description enhancement failed: %v", err)
+		}
+	}
+	if toolParallelLimits != nil {
+		registry.ParallelLimit = NewPerTypeParallelLimiter(toolParallelLimits)
+	}
+	if *toolVerificationModel != "" {
+		registry.Verify = func(ctx context.Context, result string) (bool, string, error) {
+			return verifyToolResult(ctx, client, *toolVerificationModel, result)
+		}
+	}
+
+	if *gatewayConnectionTest && *useAIGateway {
+		pingHTTPClient = httpClient
+		reportGatewayLatency(ctx, *aiGatewayURL, *gatewayLatencyWarnMs)
+	}
+
+	if *modelCapabilitiesCheck && *useAIGateway {
+		supported, err := checkModelSupportsTools(ctx, httpClient, *aiGatewayURL, *modelName)
+		switch {
+		case err != nil:
+			log.Printf("Warning: skipping model capabilities check: %v", err)
+		case !supported:
+			log.Printf("Model %s does not support tool calling", *modelName)
+			return 1
+		}
+	}
+
+	if !*replMode {
+		userQuestion = maybeRewriteQuestion(ctx, client, userQuestion)
+	}
+
+	if *sweepTemperatures != "" {
+		temperatures, err := parseTemperatures(*sweepTemperatures)
+		if err != nil {
+			log.Printf("Invalid -parameter-sweep-temperatures: %v", err)
+			return 1
+		}
+		sweep := ParameterSweep{Temperatures: temperatures, Concurrency: *concurrency}
+		results := sweep.Run(ctx, func(temp float64) (string, error) {
+			text, _, err := runConversation(ctx, client, registry, conversationOptions{Question: userQuestion, Temperature: &temp})
+			if err != nil {
+				return "", err
+			}
+			return finalizeResponse(text), nil
+		})
+		printSweepResults(os.Stdout, results)
+		return 0
+	}
+
+	if *compressSession && *sessionFile != "" {
+		*sessionFile = compressedSessionPath(*sessionFile)
+	}
+	var session *Session
+	if *sessionFile != "" {
+		if session, err = loadOrCreateSession(*sessionFile); err != nil {
+			log.Printf("Error loading session: %v", err)
+			return 1
+		}
+	} else {
+		session = newSession()
+	}
+
+	if *toolDedupWindow > 0 {
+		registry.Cache = &SessionToolCache{Window: *toolDedupWindow}
+		registry.Cache.Seed(session.Messages)
+	}
+	if *replMode {
+		if err := runREPL(ctx, client, registry, session); err != nil {
+			log.Print(err)
+			return 1
+		}
+		return 0
+	}
+	responseText, messages, err := runConversation(ctx, client, registry, conversationOptions{Question: userQuestion, History: session.Messages, ConversationID: session.Metadata.ID})
+	if errors.Is(err, errStoppedAfterTools) {
+		return 0
+	}
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	session.Messages = messages
+	applySessionTitle(session, responseText)
+	persistSession(session)
+	elements, isArray := extractJSONArrayResponse(responseText)
+	if isArray {
+		responseText = strings.Join(elements, "\n")
+	} else {
+		responseText = finalizeResponse(responseText)
+	}
+	recordAudit(session, userQuestion, responseText)
+	switch {
+	case isArray:
+		if responseText != "" {
+			fmt.Println(responseText)
+		}
+	case *outputDiffFromLast:
+		diff, err := diffFromLastResponse(*lastResponseFile, responseText)
+		if err != nil {
+			log.Printf("Warning: failed to diff against last response: %v", err)
+			log.Println("Final Response from Model:", responseText)
+		} else {
+			log.Println("Final Response from Model (changes since last run):\n" + diff)
+		}
+	default:
+		log.Println("Final Response from Model:", responseText)
+	}
+	if *outputToClipboard {
+		if err := writeToClipboard(responseText); err != nil {
+			log.Printf("Warning: failed to copy response to clipboard: %v", err)
+		}
+	}
+	if *exitCodeFromResponse {
+		return determineExitCode(responseText, *successPattern, *failurePattern)
+	}
+	return 0
+}
+
+// newClient builds the OpenAI client for the configured backend (AI Gateway or Bedrock)
+// along with the HTTP client it sends requests through
+func newClient() (*openai.Client, *http.Client, error) {
+	var clientOptions []option.RequestOption
+	if *gatewayURLFromServiceAccount && *useAIGateway {
+		if serviceURL, err := resolveKubernetesServiceURL(*gatewayK8sServiceName); err != nil {
+			log.Printf("Warning: %v, using %s", err, *aiGatewayURL)
+		} else {
+			*aiGatewayURL = serviceURL
+		}
+		if token, err := readServiceAccountToken(); err != nil {
+			log.Printf("Warning: not sending service account token: %v", err)
+		} else {
+			serviceAccountToken = token
+			clientOptions = append(clientOptions, option.WithHeader("Authorization", "Bearer "+token))
+		}
+	}
 
 	// Determine base URL (AI Gateway or Bedrock)
 	baseURL := ""
@@ -30,75 +511,182 @@ func main() {
 		baseURL = *aiGatewayURL + "/v1/"
 	} else {
 		log.Println("Using Amazon Bedrock for requests.")
+		bedrockRegions = splitCommaList(*awsBedrockRegions)
+		if len(bedrockRegions) == 0 {
+			bedrockRegions = []string{*awsRegion}
+		}
+		baseURL = bedrockEndpoint(*bedrockEndpointTemplate, bedrockRegions[0])
+	}
+
+	httpClient, err := buildHTTPClient()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Initialize OpenAI client
-	client := openai.NewClient(
+	client := openai.NewClient(append([]option.RequestOption{
 		option.WithBaseURL(baseURL),
-	)
+		option.WithHTTPClient(httpClient),
+	}, clientOptions...)...)
+	return client, httpClient, nil
+}
+
+// maybeRewriteQuestion returns the -question-rewrite version of question, or question
+// itself when rewriting is off or fails
+func maybeRewriteQuestion(ctx context.Context, client *openai.Client, question string) string {
+	if !*questionRewrite {
+		return question
+	}
+	model := *rewriteModel
+	if model == "" {
+		model = *modelName
+	}
+	rewritten, err := rewriteQuestion(ctx, client, model, question)
+	if err != nil {
+		log.Printf("Warning: question rewrite failed, using the original question: %v", err)
+		return question
+	}
+	if *verbose {
+		fmt.Fprintln(os.Stderr, "Rewritten question:", rewritten)
+	}
+	return rewritten
+}
+
+// persistSession saves the session to -session-file, if set
+func persistSession(session *Session) {
+	if *sessionFile == "" {
+		return
+	}
+	save := saveSession
+	if *compressSession {
+		save = saveSessionGzipped
+	}
+	if err := save(*sessionFile, session); err != nil {
+		log.Printf("Warning: failed to save session: %v", err)
+	}
+}
+
+// conversationOptions configures a single runConversation call
+type conversationOptions struct {
+	Question       string
+	History        []openai.ChatCompletionMessageParamUnion
+	Temperature    *float64
+	ConversationID string
+}
+
+// runConversation continues the history with the question, answers any tool calls and
+// returns the final response text along with the full message history
+func runConversation(ctx context.Context, client *openai.Client, registry *ToolRegistry, opts conversationOptions) (string, []openai.ChatCompletionMessageParamUnion, error) {
+	messages := append([]openai.ChatCompletionMessageParamUnion{}, opts.History...)
+	messages, err := applySystemPrompt(messages, opts.ConversationID)
+	if err != nil {
+		return "", nil, err
+	}
+	messages = append(messages, openai.UserMessage(opts.Question))
+	if ragIndex != nil {
+		injector := ContextInjector{Strategy: contextInjectionStrategyFor(*contextInjectionStrategy, *noSystemPrompt)}
+		messages = injector.Inject(messages, formatRAGContext(ragIndex.Search(opts.Question, ragTopK)))
+	}
+
+	tools, err := registry.ToParams()
+	if err != nil {
+		return "", nil, err
+	}
 
 	params := openai.ChatCompletionNewParams{
-		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(question),
-		}),
-		Tools: openai.F([]openai.ChatCompletionToolParam{
-			{
-				Type: openai.F(openai.ChatCompletionToolTypeFunction),
-				Function: openai.F(openai.FunctionDefinitionParam{
-					Name:        openai.String("get_weather"),
-					Description: openai.String("Get weather at the given location"),
-					Parameters: openai.F(openai.FunctionParameters{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"location": map[string]string{"type": "string"},
-						},
-						"required": []string{"location"},
-					}),
-				}),
-			},
-		}),
-		Model: openai.F("eu.anthropic.claude-3-5-sonnet-20240620-v1:0"),
+		Messages: openai.F(messages),
+		Tools:    openai.F(tools),
+		Model:    openai.F(resolveModelID(*modelName, *awsBedrockInferenceProfile)),
+	}
+	if opts.Temperature != nil {
+		params.Temperature = openai.F(*opts.Temperature)
+	}
+	if *noSystemPrompt {
+		params.Messages.Value = stripSystemMessages(params.Messages.Value)
 	}
 
 	// Step 1: Send initial request
-	response, err := sendRequest(client, params)
+	applyContextModel(&params)
+	warnInvalidParams(params)
+	var response *openai.ChatCompletion
+	if *stream {
+		response, err = streamRequest(ctx, client, params)
+	} else {
+		response, err = sendRequest(ctx, client, params)
+	}
 	if err != nil {
-		log.Fatalf("Error sending request: %v", err)
+		return "", nil, fmt.Errorf("Error sending request: %w", err)
 	}
 
-	fmt.Println(response.Choices[0].Message)
+	if !*outputOnlyToolResults {
+		fmt.Println(response.Choices[0].Message)
+	}
 
+	if *functionCallingFallback && len(response.Choices[0].Message.ToolCalls) == 0 {
+		if calls := parseFunctionCallsFromText(response.Choices[0].Message.Content, registry); len(calls) > 0 {
+			log.Printf("Parsed %d tool calls from the response text", len(calls))
+			response.Choices[0].Message.ToolCalls = calls
+		}
+	}
 	toolCalls := response.Choices[0].Message.ToolCalls
 	params.Messages.Value = append(params.Messages.Value, response.Choices[0].Message)
-	for _, toolCall := range toolCalls {
-		if toolCall.Function.Name == "get_weather" {
-			// Extract the location from the function call arguments
-			var args map[string]interface{}
-			if argErr := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); argErr != nil {
-				log.Println("Error unmarshalling the function arguments: %v", argErr)
-			}
-			location := args["location"].(string)
-			if location != "New York City" {
-				log.Println("Expected location to be New York City but got %s", location)
-			}
-			// Simulate getting weather data
-			weatherData := "Sunny, 25°C"
-			params.Messages.Value = append(params.Messages.Value, openai.ToolMessage(toolCall.ID, weatherData))
-			log.Println("Appended tool message:", openai.ToolMessage(toolCall.ID, weatherData)) // Debug log
+	dispatch := dispatchToolCallsOrdered
+	if *parallelTools && !*enforceToolOrder {
+		dispatch = dispatchToolCallsConcurrently
+	}
+	toolMessages, err := dispatch(ctx, toolCalls, registry)
+	if err != nil {
+		return "", nil, err
+	}
+	results := make([]string, 0, len(toolMessages))
+	for _, msg := range toolMessages {
+		params.Messages.Value = append(params.Messages.Value, msg)
+		log.Println("Appended tool message:", msg) // Debug log
+		results = append(results, messageText(msg))
+	}
+
+	if *outputOnlyToolResults {
+		if err := printToolResults(os.Stdout, toolCalls, results, *jsonOutput); err != nil {
+			return "", nil, err
 		}
+		return "", params.Messages.Value, errStoppedAfterTools
 	}
 
 	// Step 3: Send final request with tool response
-	finalResponse, err := sendFinalRequest(client, params)
+	applyContextModel(&params)
+	warnInvalidParams(params)
+	var finalResponse *openai.ChatCompletion
+	if *stream {
+		finalResponse, err = streamRequest(ctx, client, params)
+	} else {
+		finalResponse, err = sendFinalRequest(ctx, client, params)
+	}
 	if err != nil {
-		log.Fatalf("Error sending final request: %v", err)
+		return "", nil, fmt.Errorf("Error sending final request: %w", err)
 	}
-	log.Println("Final Response from Model:", finalResponse)
+	params.Messages.Value = append(params.Messages.Value, finalResponse.Choices[0].Message)
+	return finalResponse.Choices[0].Message.Content, params.Messages.Value, nil
+}
+
+// finalizeResponse applies the configured output transformations to the response text
+func finalizeResponse(responseText string) string {
+	if *responsePostprocess != "" {
+		processed, err := postprocessResponse(*responsePostprocess, responseText, *responsePostprocessTimeout)
+		if err != nil {
+			log.Printf("Warning: response postprocess failed, using original response: %v", err)
+		} else {
+			responseText = processed
+		}
+	}
+	if strategy, err := truncationStrategy(*responseTruncationStrategy); err == nil {
+		responseText = strategy.Truncate(responseText, truncation)
+	}
+	return responseText
 }
 
 // sendRequest sends the request using OpenAI client
-func sendRequest(client *openai.Client, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
-	resp, err := client.Chat.Completions.New(context.Background(), params)
+func sendRequest(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	resp, err := createCompletion(ctx, client, params)
 	if err != nil {
 		return nil, err
 	}
@@ -107,11 +695,58 @@ func sendRequest(client *openai.Client, params openai.ChatCompletionNewParams) (
 }
 
 // sendFinalRequest sends the tool response back to the model using OpenAI client
-func sendFinalRequest(client *openai.Client, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
-	resp, err := client.Chat.Completions.New(context.Background(), params)
+func sendFinalRequest(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	resp, err := createCompletion(ctx, client, params)
 	if err != nil {
 		return &openai.ChatCompletion{}, err
 	}
 
 	return resp, nil
 }
+
+// createCompletion sends a single completion request, sharing the result of an identical
+// in-flight request when -request-deduplication is set
+func createCompletion(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	send := func() (*openai.ChatCompletion, error) {
+		ctx, cancel := withGatewayTimeout(ctx, gatewayCallTimeoutMs())
+		defer cancel()
+		resp, err := client.Chat.Completions.New(ctx, params)
+		if err == nil {
+			trackCost(resp)
+		}
+		return resp, err
+	}
+	// Sweep requests share messages but differ in temperature, so they are never
+	// collapsed or cached
+	if params.Temperature.Present || (!*requestDeduplication && responseStore == nil) {
+		return send()
+	}
+	if responseStore != nil {
+		key, err := responseCacheKey(params)
+		if err != nil {
+			return nil, err
+		}
+		if resp, ok := responseStore.Get(key); ok {
+			log.Printf("Using cached response %s", key)
+			return resp, nil
+		}
+		uncached := send
+		send = func() (*openai.ChatCompletion, error) {
+			resp, err := uncached()
+			if err == nil {
+				if err := responseStore.Put(key, params, resp); err != nil {
+					log.Printf("Warning: failed to cache response: %v", err)
+				}
+			}
+			return resp, err
+		}
+	}
+	if !*requestDeduplication {
+		return send()
+	}
+	key, err := deduplicationKey(params)
+	if err != nil {
+		return nil, err
+	}
+	return requestDeduplicator.Do(key, send)
+}
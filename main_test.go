@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// setFlag sets a flag value for the duration of the test
+func setFlag[T any](t *testing.T, p *T, v T) {
+	t.Helper()
+	old := *p
+	*p = v
+	t.Cleanup(func() { *p = old })
+}
+
+// testToolCall builds a tool call for name with JSON arguments
+func testToolCall(id, name, arguments string) openai.ChatCompletionMessageToolCall {
+	call := openai.ChatCompletionMessageToolCall{ID: id, Type: openai.ChatCompletionMessageToolCallTypeFunction}
+	call.Function.Name = name
+	call.Function.Arguments = arguments
+	return call
+}
+
+// testToolCallsNamed builds one call with empty arguments per name, with IDs call_0, call_1, ...
+func testToolCallsNamed(names ...string) []openai.ChatCompletionMessageToolCall {
+	calls := make([]openai.ChatCompletionMessageToolCall, 0, len(names))
+	for i, name := range names {
+		calls = append(calls, testToolCall(fmt.Sprintf("call_%d", i), name, "{}"))
+	}
+	return calls
+}
+
+// stubToolRegistry returns a registry with a single tool answering with handler
+func stubToolRegistry(t *testing.T, name string, handler ToolHandler) *ToolRegistry {
+	t.Helper()
+	registry := NewToolRegistry(0)
+	err := registry.Register(Tool{
+		Name:       name,
+		Parameters: openai.FunctionParameters{"type": "object", "properties": map[string]interface{}{}},
+		Handler:    handler,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+// testCompletion decodes a chat completion with one assistant message, so that its raw JSON
+// is available to MockGatewayServer
+func testCompletion(t *testing.T, content string, toolCalls ...openai.ChatCompletionMessageToolCall) openai.ChatCompletion {
+	t.Helper()
+	message := map[string]interface{}{"role": "assistant", "content": content}
+	finish := "stop"
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		finish = "tool_calls"
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"id":      "chatcmpl-test",
+		"object":  "chat.completion",
+		"created": 1700000000,
+		"model":   "test-model",
+		"choices": []interface{}{map[string]interface{}{"index": 0, "message": message, "finish_reason": finish}},
+		"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp openai.ChatCompletion
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// testGateway is an httptest AI Gateway serving MockGatewayServer responses in order and
+// recording the chat completion request bodies
+type testGateway struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+func newTestGateway(t *testing.T, responses ...openai.ChatCompletion) *testGateway {
+	t.Helper()
+	g := &testGateway{}
+	mock := NewMockGatewayServer(0, responses).Handler()
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/chat/completions" {
+			body, _ := io.ReadAll(r.Body)
+			g.mu.Lock()
+			g.bodies = append(g.bodies, body)
+			g.mu.Unlock()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+// Requests returns the chat completion request bodies received so far
+func (g *testGateway) Requests() [][]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([][]byte(nil), g.bodies...)
+}
+
+// requestMessage is a message as sent in a chat completion request body
+type requestMessage struct {
+	Role    string
+	Content string
+}
+
+// decodeRequestMessages returns the role and text of each message in a request body
+func decodeRequestMessages(t *testing.T, body []byte) []requestMessage {
+	t.Helper()
+	var req struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	messages := make([]requestMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, requestMessage{Role: m.Role, Content: contentText(m.Content)})
+	}
+	return messages
+}
+
+// newTestClient returns a client for the gateway at baseURL that does not retry
+func newTestClient(baseURL string) *openai.Client {
+	return openai.NewClient(
+		option.WithBaseURL(baseURL+"/v1/"),
+		option.WithAPIKey("test"),
+		option.WithMaxRetries(0),
+	)
+}
+
+func TestSplitCommaList(t *testing.T) {
+	got := splitCommaList(" us-east-1, us-west-2,,eu-west-1 ")
+	if strings.Join(got, "|") != "us-east-1|us-west-2|eu-west-1" {
+		t.Errorf("splitCommaList = %q", got)
+	}
+	if got := splitCommaList(" , "); got != nil {
+		t.Errorf("splitCommaList of blanks = %q, want nil", got)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+// messageRole returns the role of a chat message param regardless of its concrete type
+func messageRole(msg openai.ChatCompletionMessageParamUnion) string {
+	switch m := msg.(type) {
+	case openai.ChatCompletionSystemMessageParam:
+		return string(openai.ChatCompletionSystemMessageParamRoleSystem)
+	case openai.ChatCompletionDeveloperMessageParam:
+		return string(openai.ChatCompletionDeveloperMessageParamRoleDeveloper)
+	case openai.ChatCompletionUserMessageParam:
+		return string(openai.ChatCompletionUserMessageParamRoleUser)
+	case openai.ChatCompletionAssistantMessageParam:
+		return string(openai.ChatCompletionAssistantMessageParamRoleAssistant)
+	case openai.ChatCompletionToolMessageParam:
+		return string(openai.ChatCompletionToolMessageParamRoleTool)
+	case openai.ChatCompletionFunctionMessageParam:
+		return string(openai.ChatCompletionFunctionMessageParamRoleFunction)
+	case openai.ChatCompletionMessage:
+		return string(m.Role)
+	case openai.ChatCompletionMessageParam:
+		return string(m.Role.Value)
+	}
+	return ""
+}
+
+// toolCallID returns the tool call a tool message responds to, or "" for other messages
+func toolCallID(msg openai.ChatCompletionMessageParamUnion) string {
+	switch m := msg.(type) {
+	case openai.ChatCompletionToolMessageParam:
+		return m.ToolCallID.Value
+	case openai.ChatCompletionMessageParam:
+		return m.ToolCallID.Value
+	}
+	return ""
+}
+
+// stripSystemMessages returns msgs without any system-role messages
+func stripSystemMessages(msgs []openai.ChatCompletionMessageParamUnion) []openai.ChatCompletionMessageParamUnion {
+	stripped := make([]openai.ChatCompletionMessageParamUnion, 0, len(msgs))
+	for _, msg := range msgs {
+		if messageRole(msg) == string(openai.ChatCompletionSystemMessageParamRoleSystem) {
+			continue
+		}
+		stripped = append(stripped, msg)
+	}
+	return stripped
+}
+
+// checkSystemPromptFlags rejects asking for a system prompt and no system prompt at the same time
+func checkSystemPromptFlags(noSystemPrompt bool, systemPrompt string) error {
+	if noSystemPrompt && systemPrompt != "" {
+		return errors.New("-no-system-prompt and -system-prompt cannot be used together")
+	}
+	return nil
+}
+
+// messageText returns the concatenated text content of a message
+func messageText(msg openai.ChatCompletionMessageParamUnion) string {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+	var m struct {
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return ""
+	}
+	return contentText(m.Content)
+}
+
+// contentText extracts text from a message content that is either a string or an array of content parts
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// prependSystemContext prepends text to the first system message, adding a system
+// message at the start when there is none
+func prependSystemContext(msgs []openai.ChatCompletionMessageParamUnion, text string) []openai.ChatCompletionMessageParamUnion {
+	if text == "" {
+		return msgs
+	}
+	out := append([]openai.ChatCompletionMessageParamUnion{}, msgs...)
+	for i, msg := range out {
+		if messageRole(msg) == string(openai.ChatCompletionSystemMessageParamRoleSystem) {
+			out[i] = openai.SystemMessage(text + "\n\n" + messageText(msg))
+			return out
+		}
+	}
+	return append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(text)}, out...)
+}
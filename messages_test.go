@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestStripSystemMessages(t *testing.T) {
+	msgs := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("be brief"),
+		openai.UserMessage("hi"),
+		openai.SystemMessage("few-shot"),
+		openai.AssistantMessage("hello"),
+	}
+	got := stripSystemMessages(msgs)
+	if len(got) != 2 || messageRole(got[0]) != "user" || messageRole(got[1]) != "assistant" {
+		t.Errorf("stripSystemMessages kept %d messages: %v", len(got), got)
+	}
+}
+
+func TestNoSystemPromptRequestBody(t *testing.T) {
+	setFlag(t, noSystemPrompt, true)
+	gateway := newTestGateway(t, testCompletion(t, "checking"), testCompletion(t, "Sunny"))
+	registry := stubToolRegistry(t, "get_weather", nil)
+	history := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are a weather bot."),
+		openai.UserMessage("Hello"),
+		openai.AssistantMessage("Hi!"),
+	}
+
+	_, _, err := runConversation(context.Background(), newTestClient(gateway.URL), registry, conversationOptions{Question: "Weather?", History: history})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := gateway.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("gateway got %d requests, want 2", len(requests))
+	}
+	for i, body := range requests {
+		var req struct {
+			Messages []struct {
+				Role string `json:"role"`
+			} `json:"messages"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range req.Messages {
+			if m.Role == "system" {
+				t.Errorf("request %d contains a system message: %s", i, body)
+			}
+		}
+	}
+}
+
+func TestCheckSystemPromptFlags(t *testing.T) {
+	if err := checkSystemPromptFlags(true, "be brief"); err == nil {
+		t.Error("-no-system-prompt with -system-prompt was accepted")
+	}
+	if err := checkSystemPromptFlags(true, ""); err != nil {
+		t.Errorf("-no-system-prompt alone rejected: %v", err)
+	}
+}
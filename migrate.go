@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	openai "github.com/openai/openai-go"
+)
+
+// currentSessionVersion is the schema version written by saveSession
+const currentSessionVersion = 2
+
+// sessionMigrations[i] upgrades saved messages from schema version i to i+1
+var sessionMigrations = []func(messages []map[string]interface{}) error{
+	migrateV0ToV1,
+	migrateV1ToV2,
+}
+
+// migrateSession upgrades a raw session file to the current schema version and decodes its messages
+func migrateSession(raw json.RawMessage) ([]openai.ChatCompletionMessageParamUnion, error) {
+	var file struct {
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+	if err := upgradeMessages(file.Messages, file.Metadata.Version); err != nil {
+		return nil, err
+	}
+
+	encoded := make([]json.RawMessage, 0, len(file.Messages))
+	for _, m := range file.Messages {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, data)
+	}
+	return decodeMessages(encoded)
+}
+
+// upgradeMessages applies every migration from version up to currentSessionVersion in place
+func upgradeMessages(messages []map[string]interface{}, version int) error {
+	if version < 0 || version > currentSessionVersion {
+		return fmt.Errorf("unsupported session version %d (latest is %d)", version, currentSessionVersion)
+	}
+	for v := version; v < currentSessionVersion; v++ {
+		if err := sessionMigrations[v](messages); err != nil {
+			return fmt.Errorf("migrating session from version %d to %d: %w", v, v+1, err)
+		}
+	}
+	return nil
+}
+
+// migrateV0ToV1 gives every message a stable id based on its position
+func migrateV0ToV1(messages []map[string]interface{}) error {
+	for i, m := range messages {
+		if _, ok := m["id"]; !ok {
+			m["id"] = fmt.Sprintf("msg_%d", i)
+		}
+	}
+	return nil
+}
+
+// migrateV1ToV2 converts plain string content into an array of text content parts
+func migrateV1ToV2(messages []map[string]interface{}) error {
+	for _, m := range messages {
+		if text, ok := m["content"].(string); ok {
+			m["content"] = []interface{}{
+				map[string]interface{}{"type": "text", "text": text},
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadFixtureMessages(t *testing.T, name string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	return file.Messages
+}
+
+func TestMigrationSteps(t *testing.T) {
+	messages := loadFixtureMessages(t, "session_v0.json")
+
+	if err := migrateV0ToV1(messages); err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range messages {
+		if want := fmt.Sprintf("msg_%d", i); m["id"] != want {
+			t.Errorf("v1 message %d has id %v, want %s", i, m["id"], want)
+		}
+		if _, ok := m["content"].(string); !ok {
+			t.Errorf("v1 message %d content is no longer a string", i)
+		}
+	}
+
+	if err := migrateV1ToV2(messages); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{map[string]interface{}{"type": "text", "text": "Sunny, 25°C"}}
+	if !reflect.DeepEqual(messages[2]["content"], want) {
+		t.Errorf("v2 tool content = %#v, want %#v", messages[2]["content"], want)
+	}
+	if messages[2]["tool_call_id"] != "call_1" {
+		t.Error("v2 migration dropped tool_call_id")
+	}
+}
+
+func TestLoadSessionMigratesVersion0(t *testing.T) {
+	session, err := loadSession(filepath.Join("testdata", "session_v0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Metadata.Version != currentSessionVersion || session.Metadata.ID != "legacy0001" {
+		t.Errorf("metadata = %+v", session.Metadata)
+	}
+	wantRoles := []string{"user", "assistant", "tool", "assistant"}
+	if len(session.Messages) != len(wantRoles) {
+		t.Fatalf("got %d messages, want %d", len(session.Messages), len(wantRoles))
+	}
+	for i, msg := range session.Messages {
+		if messageRole(msg) != wantRoles[i] {
+			t.Errorf("message %d role = %s, want %s", i, messageRole(msg), wantRoles[i])
+		}
+	}
+	if got := messageText(session.Messages[2]); got != "Sunny, 25°C" {
+		t.Errorf("tool message text = %q", got)
+	}
+	if got := toolCallID(session.Messages[2]); got != "call_1" {
+		t.Errorf("tool message tool_call_id = %q", got)
+	}
+
+	saved, err := json.Marshal(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Metadata ConversationMetadata     `json:"metadata"`
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(saved, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Metadata.Version != 2 {
+		t.Errorf("re-saved version = %d, want 2", file.Metadata.Version)
+	}
+	if _, ok := file.Messages[0]["content"].([]interface{}); !ok {
+		t.Errorf("re-saved user content is %T, want a content part array", file.Messages[0]["content"])
+	}
+}
+
+func TestUpgradeMessagesRejectsFutureVersions(t *testing.T) {
+	if err := upgradeMessages(nil, currentSessionVersion+1); err == nil {
+		t.Error("a newer schema version was accepted")
+	}
+}
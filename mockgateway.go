@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	openai "github.com/openai/openai-go"
+)
+
+// mockGatewayDefaultModel is listed by /v1/models when no response names a model
+const mockGatewayDefaultModel = "mock-model"
+
+// MockGatewayServer is a local stand-in for the AI Gateway that answers chat
+// completions with canned responses in round-robin order
+type MockGatewayServer struct {
+	Port      int
+	Responses []openai.ChatCompletion
+
+	mu   sync.Mutex
+	next int
+}
+
+// runMockGateway implements the mock-gateway subcommand
+func runMockGateway(args []string) error {
+	fs := flag.NewFlagSet("mock-gateway", flag.ExitOnError)
+	port := fs.Int("port", 8080, "Port to listen on")
+	responseFile := fs.String("response-file", "", "JSONL file of chat completions to serve in order")
+	fs.Parse(args)
+
+	if *responseFile == "" {
+		return errors.New("mock-gateway: -response-file is required")
+	}
+	responses, err := loadMockResponses(*responseFile)
+	if err != nil {
+		return err
+	}
+	return NewMockGatewayServer(*port, responses).Start()
+}
+
+// loadMockResponses reads one chat completion per non-empty line of path
+func loadMockResponses(path string) ([]openai.ChatCompletion, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var responses []openai.ChatCompletion
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var resp openai.ChatCompletion
+		if err := json.Unmarshal([]byte(text), &resp); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		responses = append(responses, resp)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("%s has no responses", path)
+	}
+	return responses, nil
+}
+
+// NewMockGatewayServer returns a server for port that serves responses in order
+func NewMockGatewayServer(port int, responses []openai.ChatCompletion) *MockGatewayServer {
+	return &MockGatewayServer{Port: port, Responses: responses}
+}
+
+// Start serves until the listener fails
+func (s *MockGatewayServer) Start() error {
+	addr := fmt.Sprintf(":%d", s.Port)
+	log.Printf("Mock gateway listening on %s with %d responses", addr, len(s.Responses))
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// Handler returns the gateway's routes
+func (s *MockGatewayServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/models", s.handleModels)
+	mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	return logRequests(mux)
+}
+
+// logRequests logs every request to stderr
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s %s", r.Method, r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// nextResponse returns the next canned response, wrapping around at the end
+func (s *MockGatewayServer) nextResponse() openai.ChatCompletion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := s.Responses[s.next]
+	s.next = (s.next + 1) % len(s.Responses)
+	return resp
+}
+
+func (s *MockGatewayServer) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Stream bool `json:"stream"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := s.nextResponse()
+	if req.Stream {
+		writeMockStream(w, resp)
+		return
+	}
+	body := []byte(resp.JSON.RawJSON())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// writeMockStream sends resp as a single server-sent chunk followed by [DONE]
+func writeMockStream(w http.ResponseWriter, resp openai.ChatCompletion) {
+	type toolCallDelta struct {
+		Index int `json:"index"`
+		openai.ChatCompletionMessageToolCall
+	}
+	choices := make([]map[string]interface{}, 0, len(resp.Choices))
+	for _, c := range resp.Choices {
+		toolCalls := make([]toolCallDelta, 0, len(c.Message.ToolCalls))
+		for i, tc := range c.Message.ToolCalls {
+			toolCalls = append(toolCalls, toolCallDelta{Index: i, ChatCompletionMessageToolCall: tc})
+		}
+		delta := map[string]interface{}{"role": "assistant", "content": c.Message.Content}
+		if len(toolCalls) > 0 {
+			delta["tool_calls"] = toolCalls
+		}
+		choices = append(choices, map[string]interface{}{
+			"index":         c.Index,
+			"delta":         delta,
+			"finish_reason": c.FinishReason,
+		})
+	}
+	chunk, err := json.Marshal(map[string]interface{}{
+		"id":      resp.ID,
+		"object":  "chat.completion.chunk",
+		"created": resp.Created,
+		"model":   resp.Model,
+		"choices": choices,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", chunk)
+}
+
+func (s *MockGatewayServer) handleModels(w http.ResponseWriter, r *http.Request) {
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+	seen := map[string]bool{}
+	var models []model
+	for _, resp := range s.Responses {
+		if resp.Model != "" && !seen[resp.Model] {
+			seen[resp.Model] = true
+			models = append(models, model{ID: resp.Model, Object: "model", OwnedBy: "mock-gateway"})
+		}
+	}
+	if len(models) == 0 {
+		models = append(models, model{ID: mockGatewayDefaultModel, Object: "model", OwnedBy: "mock-gateway"})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": models})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestMockGatewayRoundRobin(t *testing.T) {
+	var lines []string
+	for _, content := range []string{"first", "second", "third"} {
+		data, err := json.Marshal(testCompletion(t, content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(data))
+	}
+	path := filepath.Join(t.TempDir(), "responses.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	responses, err := loadMockResponses(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewMockGatewayServer(0, responses).Handler())
+	defer srv.Close()
+	client := newTestClient(srv.URL)
+
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}),
+		Model:    openai.F("test-model"),
+	}
+	for i, want := range []string{"first", "second", "third", "first", "second"} {
+		resp, err := client.Chat.Completions.New(context.Background(), params)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if got := resp.Choices[0].Message.Content; got != want {
+			t.Errorf("request %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestMockGatewayRoutes(t *testing.T) {
+	srv := httptest.NewServer(NewMockGatewayServer(0, []openai.ChatCompletion{testCompletion(t, "Sunny")}).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("/health = %s", resp.Status)
+	}
+
+	models, err := newTestClient(srv.URL).Models.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models.Data) != 1 || models.Data[0].ID != "test-model" {
+		t.Errorf("/v1/models = %+v", models.Data)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/chat/completions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /v1/chat/completions = %s, want 405", resp.Status)
+	}
+}
+
+func TestLoadMockResponsesErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"empty.jsonl": "\n\n", "invalid.jsonl": "{not json}\n"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadMockResponses(path); err == nil {
+			t.Errorf("loadMockResponses(%s) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	openai "github.com/openai/openai-go"
+)
+
+// charsPerToken is the rough number of characters per token used for estimates
+const charsPerToken = 4
+
+// ModelTier is one entry of -context-model-tiers; a zero MaxTokens has no upper bound
+type ModelTier struct {
+	MaxTokens int    `json:"max_tokens,omitempty"`
+	Model     string `json:"model"`
+}
+
+// contextModelTiers holds the parsed -context-model-tiers, cheapest first
+var contextModelTiers []ModelTier
+
+// parseModelTiers decodes the -context-model-tiers JSON array
+func parseModelTiers(value string) ([]ModelTier, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var tiers []ModelTier
+	if err := json.Unmarshal([]byte(value), &tiers); err != nil {
+		return nil, err
+	}
+	if len(tiers) == 0 {
+		return nil, errors.New("no tiers given")
+	}
+	for i, tier := range tiers {
+		if tier.Model == "" {
+			return nil, fmt.Errorf("tier %d has no model", i)
+		}
+		if tier.MaxTokens < 0 {
+			return nil, fmt.Errorf("tier %d has negative max_tokens", i)
+		}
+	}
+	return tiers, nil
+}
+
+// checkContextModelTierFlags rejects -context-model-tiers together with
+// -aws-bedrock-inference-profile, since a tier model would replace the chosen profile
+func checkContextModelTierFlags(tiers []ModelTier, inferenceProfile string) error {
+	if len(tiers) > 0 && inferenceProfile != "" {
+		return errors.New("-context-model-tiers and -aws-bedrock-inference-profile cannot be used together")
+	}
+	return nil
+}
+
+// selectModelForContext returns the first tier whose max_tokens exceeds estimatedTokens,
+// falling back to the last tier when every limit is too small
+func selectModelForContext(tiers []ModelTier, estimatedTokens int) string {
+	for _, tier := range tiers {
+		if tier.MaxTokens == 0 || tier.MaxTokens > estimatedTokens {
+			return tier.Model
+		}
+	}
+	return tiers[len(tiers)-1].Model
+}
+
+// estimateTokens approximates the token count of the messages' text
+func estimateTokens(messages []openai.ChatCompletionMessageParamUnion) int {
+	chars := 0
+	for _, msg := range messages {
+		chars += len(messageText(msg))
+	}
+	return chars / charsPerToken
+}
+
+// applyContextModel switches params to the tier model for its current history when
+// -context-model-tiers is set
+func applyContextModel(params *openai.ChatCompletionNewParams) {
+	if len(contextModelTiers) == 0 {
+		return
+	}
+	estimate := estimateTokens(params.Messages.Value)
+	model := selectModelForContext(contextModelTiers, estimate)
+	log.Printf("Selected model %s for an estimated %d tokens of context", model, estimate)
+	params.Model = openai.F(model)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+const testModelTiers = `[{"max_tokens":4000,"model":"titan-fast"},{"max_tokens":32000,"model":"claude-haiku"},{"model":"claude-sonnet"}]`
+
+func TestSelectModelForContext(t *testing.T) {
+	tiers, err := parseModelTiers(testModelTiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tokens int
+		want   string
+	}{
+		{0, "titan-fast"},
+		{3999, "titan-fast"},
+		{4000, "claude-haiku"},
+		{31999, "claude-haiku"},
+		{100000, "claude-sonnet"},
+	}
+	for _, tt := range tests {
+		if got := selectModelForContext(tiers, tt.tokens); got != tt.want {
+			t.Errorf("selectModelForContext(%d) = %s, want %s", tt.tokens, got, tt.want)
+		}
+	}
+
+	bounded := []ModelTier{{MaxTokens: 10, Model: "small"}, {MaxTokens: 100, Model: "large"}}
+	if got := selectModelForContext(bounded, 1000); got != "large" {
+		t.Errorf("over every limit = %s, want the last tier", got)
+	}
+}
+
+func TestApplyContextModel(t *testing.T) {
+	tiers, err := parseModelTiers(testModelTiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, &contextModelTiers, tiers)
+	tests := []struct {
+		name    string
+		history []openai.ChatCompletionMessageParamUnion
+		want    string
+	}{
+		{"short history", []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}, "titan-fast"},
+		{"long history", []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(strings.Repeat("context ", 10000)),
+			openai.UserMessage(strings.Repeat("more context ", 10000)),
+		}, "claude-sonnet"},
+	}
+	for _, tt := range tests {
+		params := openai.ChatCompletionNewParams{Messages: openai.F(tt.history), Model: openai.F("default-model")}
+		applyContextModel(&params)
+		if params.Model.Value != tt.want {
+			t.Errorf("%s: model = %s, want %s", tt.name, params.Model.Value, tt.want)
+		}
+	}
+}
+
+func TestParseModelTiersErrors(t *testing.T) {
+	for _, bad := range []string{`[]`, `[{"max_tokens":10}]`, `[{"max_tokens":-1,"model":"m"}]`, `{`} {
+		if _, err := parseModelTiers(bad); err == nil {
+			t.Errorf("parseModelTiers(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestCheckContextModelTierFlags(t *testing.T) {
+	tiers, err := parseModelTiers(testModelTiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := "us.anthropic.claude-3-5-sonnet-20241022-v2:0"
+	if err := checkContextModelTierFlags(tiers, profile); err == nil {
+		t.Error("tiers with an inference profile were accepted")
+	}
+	if err := checkContextModelTierFlags(tiers, ""); err != nil {
+		t.Errorf("tiers without an inference profile: %v", err)
+	}
+	if err := checkContextModelTierFlags(nil, profile); err != nil {
+		t.Errorf("inference profile without tiers: %v", err)
+	}
+}
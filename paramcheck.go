@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+// Warnings returned by validateChatCompletionParams
+const (
+	paramWarningToolsWithN           = "tools with n > 1: only the first choice's tool calls are dispatched"
+	paramWarningToolsWithJSONObject  = "tools with response_format json_object: the model may answer in JSON instead of calling tools"
+	paramWarningTemperatureWithTopP  = "temperature > 1 with top_p != 1: adjust temperature or top_p, not both"
+	paramWarningMaxTokensOverContext = "max_tokens plus the estimated prompt exceeds the model context window"
+)
+
+// modelContextWindows are the context window sizes in tokens, matched against model names
+// by substring, most specific first
+var modelContextWindows = []struct {
+	Match  string
+	Tokens int
+}{
+	{"claude-3", 200000},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-3.5-turbo", 16385},
+}
+
+// contextWindow returns the context window for model, or 0 when it is unknown
+func contextWindow(model string) int {
+	for _, w := range modelContextWindows {
+		if strings.Contains(model, w.Match) {
+			return w.Tokens
+		}
+	}
+	return 0
+}
+
+// validateChatCompletionParams reports known problematic parameter combinations that the
+// API accepts without complaint
+func validateChatCompletionParams(p openai.ChatCompletionNewParams) []string {
+	var warnings []string
+	hasTools := len(p.Tools.Value) > 0
+	if hasTools && p.N.Value > 1 {
+		warnings = append(warnings, paramWarningToolsWithN)
+	}
+	if hasTools && isJSONObjectFormat(p.ResponseFormat.Value) {
+		warnings = append(warnings, paramWarningToolsWithJSONObject)
+	}
+	if p.Temperature.Value > 1 && p.TopP.Present && p.TopP.Value != 1 {
+		warnings = append(warnings, paramWarningTemperatureWithTopP)
+	}
+	maxTokens := p.MaxTokens.Value
+	if p.MaxCompletionTokens.Value > maxTokens {
+		maxTokens = p.MaxCompletionTokens.Value
+	}
+	if window := contextWindow(p.Model.Value); window > 0 && maxTokens > 0 &&
+		int(maxTokens)+estimateTokens(p.Messages.Value) > window {
+		warnings = append(warnings, paramWarningMaxTokensOverContext)
+	}
+	return warnings
+}
+
+// isJSONObjectFormat reports whether format requests json_object output
+func isJSONObjectFormat(format openai.ChatCompletionNewParamsResponseFormatUnion) bool {
+	switch f := format.(type) {
+	case openai.ResponseFormatJSONObjectParam:
+		return true
+	case openai.ChatCompletionNewParamsResponseFormat:
+		return f.Type.Value == openai.ChatCompletionNewParamsResponseFormatTypeJSONObject
+	}
+	return false
+}
+
+// warnInvalidParams logs the validateChatCompletionParams findings when -validate-openai-params is set
+func warnInvalidParams(p openai.ChatCompletionNewParams) {
+	if !*validateOpenAIParams {
+		return
+	}
+	for _, warning := range validateChatCompletionParams(p) {
+		log.Printf("Warning: request params: %s", warning)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestValidateChatCompletionParams(t *testing.T) {
+	tools, err := stubToolRegistry(t, "get_weather", nil).ToParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 40000 characters estimate 10000 tokens
+	longPrompt := []openai.ChatCompletionMessageParamUnion{openai.UserMessage(strings.Repeat("a", 40000))}
+	base := func() openai.ChatCompletionNewParams {
+		return openai.ChatCompletionNewParams{
+			Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}),
+			Model:    openai.F("gpt-4o"),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*openai.ChatCompletionNewParams)
+		want   []string
+	}{
+		{"clean", func(p *openai.ChatCompletionNewParams) {
+			p.Tools = openai.F(tools)
+			p.Temperature = openai.F(0.7)
+			p.TopP = openai.F(0.9)
+		}, nil},
+		{"tools with n", func(p *openai.ChatCompletionNewParams) {
+			p.Tools = openai.F(tools)
+			p.N = openai.F(int64(2))
+		}, []string{paramWarningToolsWithN}},
+		{"n without tools", func(p *openai.ChatCompletionNewParams) {
+			p.N = openai.F(int64(2))
+		}, nil},
+		{"tools with json_object", func(p *openai.ChatCompletionNewParams) {
+			p.Tools = openai.F(tools)
+			p.ResponseFormat = openai.F[openai.ChatCompletionNewParamsResponseFormatUnion](openai.ResponseFormatJSONObjectParam{
+				Type: openai.F(openai.ResponseFormatJSONObjectTypeJSONObject),
+			})
+		}, []string{paramWarningToolsWithJSONObject}},
+		{"temperature with top_p", func(p *openai.ChatCompletionNewParams) {
+			p.Temperature = openai.F(1.5)
+			p.TopP = openai.F(0.5)
+		}, []string{paramWarningTemperatureWithTopP}},
+		{"temperature with top_p 1", func(p *openai.ChatCompletionNewParams) {
+			p.Temperature = openai.F(1.5)
+			p.TopP = openai.F(1.0)
+		}, nil},
+		{"max_tokens over context", func(p *openai.ChatCompletionNewParams) {
+			p.Messages = openai.F(longPrompt)
+			p.MaxTokens = openai.F(int64(120000))
+		}, []string{paramWarningMaxTokensOverContext}},
+		{"max_completion_tokens over context", func(p *openai.ChatCompletionNewParams) {
+			p.Messages = openai.F(longPrompt)
+			p.MaxCompletionTokens = openai.F(int64(120000))
+		}, []string{paramWarningMaxTokensOverContext}},
+		{"max_tokens within context", func(p *openai.ChatCompletionNewParams) {
+			p.Messages = openai.F(longPrompt)
+			p.MaxTokens = openai.F(int64(100000))
+		}, nil},
+		{"unknown model window", func(p *openai.ChatCompletionNewParams) {
+			p.Model = openai.F("test-model")
+			p.Messages = openai.F(longPrompt)
+			p.MaxTokens = openai.F(int64(1000000))
+		}, nil},
+		{"all", func(p *openai.ChatCompletionNewParams) {
+			p.Tools = openai.F(tools)
+			p.N = openai.F(int64(3))
+			p.ResponseFormat = openai.F[openai.ChatCompletionNewParamsResponseFormatUnion](openai.ResponseFormatJSONObjectParam{
+				Type: openai.F(openai.ResponseFormatJSONObjectTypeJSONObject),
+			})
+			p.Temperature = openai.F(2.0)
+			p.TopP = openai.F(0.1)
+			p.Messages = openai.F(longPrompt)
+			p.MaxTokens = openai.F(int64(120000))
+		}, []string{paramWarningToolsWithN, paramWarningToolsWithJSONObject, paramWarningTemperatureWithTopP, paramWarningMaxTokensOverContext}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := base()
+			tt.modify(&p)
+			if got := validateChatCompletionParams(p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("warnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// gatewayPingCount is the number of /health requests made by -gateway-connection-test
+const gatewayPingCount = 3
+
+// pingHTTPClient sends the -gateway-connection-test pings
+var pingHTTPClient = http.DefaultClient
+
+// PingStats summarizes the round-trip times of the successful pings
+type PingStats struct {
+	Count    int
+	Failures int
+	Min      time.Duration
+	Avg      time.Duration
+	Max      time.Duration
+}
+
+// pingGateway sends count sequential GET <url>/health requests. Stats cover the
+// successful pings; the error reports the failed ones.
+func pingGateway(ctx context.Context, url string, count int) (PingStats, error) {
+	var stats PingStats
+	var total time.Duration
+	var errs []error
+	for i := 0; i < count; i++ {
+		rtt, err := pingOnce(ctx, strings.TrimSuffix(url, "/")+"/health")
+		if err != nil {
+			stats.Failures++
+			errs = append(errs, fmt.Errorf("ping %d: %w", i+1, err))
+			continue
+		}
+		if stats.Count == 0 || rtt < stats.Min {
+			stats.Min = rtt
+		}
+		if rtt > stats.Max {
+			stats.Max = rtt
+		}
+		stats.Count++
+		total += rtt
+	}
+	if stats.Count > 0 {
+		stats.Avg = total / time.Duration(stats.Count)
+	}
+	return stats, errors.Join(errs...)
+}
+
+// pingOnce times a single health check request
+func pingOnce(ctx context.Context, url string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := pingHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	rtt := time.Since(start)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("health check returned %s", resp.Status)
+	}
+	return rtt, nil
+}
+
+// reportGatewayLatency runs the -gateway-connection-test pings and logs the results;
+// failures are logged but never abort the run
+func reportGatewayLatency(ctx context.Context, url string, warnMs int) {
+	stats, err := pingGateway(ctx, url, gatewayPingCount)
+	if err != nil {
+		log.Printf("Error: gateway connection test: %v", err)
+	}
+	if stats.Count == 0 {
+		return
+	}
+	log.Printf("Gateway latency: min=%dms, avg=%dms, max=%dms", stats.Min.Milliseconds(), stats.Avg.Milliseconds(), stats.Max.Milliseconds())
+	if warnMs > 0 && stats.Avg > time.Duration(warnMs)*time.Millisecond {
+		log.Printf("Warning: average gateway latency %dms exceeds %dms", stats.Avg.Milliseconds(), warnMs)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPingGatewayLatency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	stats, err := pingGateway(context.Background(), srv.URL+"/", gatewayPingCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != gatewayPingCount || stats.Failures != 0 {
+		t.Errorf("stats = %+v, want %d successful pings", stats, gatewayPingCount)
+	}
+	for name, d := range map[string]time.Duration{"min": stats.Min, "avg": stats.Avg, "max": stats.Max} {
+		if d < 5*time.Millisecond || d > 15*time.Millisecond {
+			t.Errorf("%s = %v, want 10ms ± 5ms", name, d)
+		}
+	}
+	if stats.Min > stats.Avg || stats.Avg > stats.Max {
+		t.Errorf("stats out of order: %+v", stats)
+	}
+}
+
+func TestPingGatewayFailures(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	stats, err := pingGateway(context.Background(), srv.URL, 3)
+	if err == nil {
+		t.Error("a failed ping was not reported")
+	}
+	if stats.Count != 2 || stats.Failures != 1 {
+		t.Errorf("stats = %+v, want 2 successes and 1 failure", stats)
+	}
+}
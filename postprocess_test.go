@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeScript writes an executable shell script to a temp dir and returns its path
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	path := filepath.Join(t.TempDir(), "postprocess.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPostprocessResponseEchoesStdin(t *testing.T) {
+	cat := writeScript(t, "cat")
+	got, err := postprocessResponse(cat, "Sunny, 25°C\n", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Sunny, 25°C\n" {
+		t.Errorf("got %q, want the input echoed back", got)
+	}
+}
+
+func TestFinalizeResponseKeepsOriginalOnFailure(t *testing.T) {
+	setFlag(t, responsePostprocess, writeScript(t, "echo broken >&2; exit 3"))
+	if got := finalizeResponse("original"); got != "original" {
+		t.Errorf("got %q, want the original response", got)
+	}
+}
+
+func TestFinalizeResponseUsesPostprocessOutput(t *testing.T) {
+	setFlag(t, responsePostprocess, writeScript(t, "tr a-z A-Z"))
+	if got := finalizeResponse("sunny"); got != "SUNNY" {
+		t.Errorf("got %q, want SUNNY", got)
+	}
+}
+
+func TestValidateExecutable(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.txt")
+	if err := os.WriteFile(plain, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateExecutable(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing executable was accepted")
+	}
+	if runtime.GOOS != "windows" {
+		if err := validateExecutable(plain); err == nil {
+			t.Error("non-executable file was accepted")
+		}
+	}
+	if err := validateExecutable(writeScript(t, "cat")); err != nil {
+		t.Errorf("executable script rejected: %v", err)
+	}
+}
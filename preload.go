@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// preloadQuestion is the synthetic prompt sent by preload-test; it keeps responses tiny
+const preloadQuestion = "Say 'OK' and nothing else."
+
+// preloadMaxTokens caps each preload-test response
+const preloadMaxTokens = 5
+
+// preloadErrorBackoff is how long a worker waits after a failed request, so that a backend
+// refusing connections is not hammered in a tight loop
+const preloadErrorBackoff = 100 * time.Millisecond
+
+// PreloadTestRunner keeps Concurrency requests in flight against the backend for
+// Duration to warm up connection pools and KV caches
+type PreloadTestRunner struct {
+	Client      *openai.Client
+	Model       string
+	Concurrency int
+	Duration    time.Duration
+}
+
+// PreloadTestReport summarizes a preload-test run
+type PreloadTestReport struct {
+	Successes       int
+	Errors          int
+	AverageLatency  time.Duration
+	P95Latency      time.Duration
+	TokensPerSecond float64
+	Elapsed         time.Duration
+}
+
+// runPreloadTest implements the preload-test subcommand. The backend flags of the main
+// command (-ai-gateway-url, -model-name, ...) are accepted as well.
+func runPreloadTest(args []string) error {
+	fs := newSubcommandFlagSet("preload-test")
+	concurrency := fs.Int("preload-concurrency", 5, "Number of parallel requests")
+	duration := fs.Duration("preload-duration", 10*time.Second, "How long to keep sending requests")
+	fs.Parse(args)
+
+	if *concurrency < 1 {
+		return fmt.Errorf("preload-test: -preload-concurrency must be at least 1")
+	}
+	client, _, err := newClient()
+	if err != nil {
+		return err
+	}
+	runner := &PreloadTestRunner{Client: client, Model: *modelName, Concurrency: *concurrency, Duration: *duration}
+	printPreloadReport(os.Stdout, runner.Run(context.Background()))
+	return nil
+}
+
+// Run sends requests until Duration has passed or ctx is done
+func (r *PreloadTestRunner) Run(ctx context.Context) PreloadTestReport {
+	ctx, cancel := context.WithTimeout(ctx, r.Duration)
+	defer cancel()
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(preloadQuestion),
+		}),
+		Model:     openai.F(r.Model),
+		MaxTokens: openai.Int(preloadMaxTokens),
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errCount  int
+		tokens    int64
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < r.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				sent := time.Now()
+				// Sent directly so -request-deduplication and -response-cache-dir do not absorb the load
+				resp, err := r.Client.Chat.Completions.New(ctx, params)
+				latency := time.Since(sent)
+				if err != nil && ctx.Err() != nil {
+					return // cut off by the end of the run
+				}
+				mu.Lock()
+				if err != nil {
+					errCount++
+				} else {
+					latencies = append(latencies, latency)
+					tokens += resp.Usage.CompletionTokens
+				}
+				mu.Unlock()
+				if err != nil {
+					sleepContext(ctx, preloadErrorBackoff)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := PreloadTestReport{Successes: len(latencies), Errors: errCount, Elapsed: time.Since(start)}
+	if len(latencies) > 0 {
+		var total time.Duration
+		for _, l := range latencies {
+			total += l
+		}
+		report.AverageLatency = total / time.Duration(len(latencies))
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.P95Latency = latencies[(len(latencies)*95+99)/100-1]
+	}
+	if secs := report.Elapsed.Seconds(); secs > 0 {
+		report.TokensPerSecond = float64(tokens) / secs
+	}
+	return report
+}
+
+// printPreloadReport writes the report as an aligned summary
+func printPreloadReport(w io.Writer, r PreloadTestReport) {
+	fmt.Fprintf(w, "Successful requests: %d\n", r.Successes)
+	fmt.Fprintf(w, "Failed requests:     %d\n", r.Errors)
+	fmt.Fprintf(w, "Average latency:     %s\n", r.AverageLatency.Round(time.Millisecond))
+	fmt.Fprintf(w, "p95 latency:         %s\n", r.P95Latency.Round(time.Millisecond))
+	fmt.Fprintf(w, "Tokens/second:       %.1f\n", r.TokensPerSecond)
+	fmt.Fprintf(w, "Duration:            %s\n", r.Elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestPreloadTestRunner(t *testing.T) {
+	var requests, badBodies int32
+	mock := NewMockGatewayServer(0, []openai.ChatCompletion{testCompletion(t, "OK")}).Handler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			MaxTokens int `json:"max_tokens"`
+			Messages  []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		if json.Unmarshal(body, &req) != nil || req.MaxTokens != preloadMaxTokens ||
+			len(req.Messages) != 1 || contentText(req.Messages[0].Content) != preloadQuestion {
+			atomic.AddInt32(&badBodies, 1)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if n%4 == 0 {
+			http.Error(w, "overloaded", http.StatusInternalServerError)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mock.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	runner := &PreloadTestRunner{Client: newTestClient(srv.URL), Model: "test-model", Concurrency: 3, Duration: 300 * time.Millisecond}
+	report := runner.Run(context.Background())
+
+	if badBodies > 0 {
+		t.Errorf("%d requests did not carry the preload question with max_tokens %d", badBodies, preloadMaxTokens)
+	}
+	if report.Successes == 0 || report.Errors == 0 {
+		t.Fatalf("report = %+v, want both successes and errors", report)
+	}
+	if report.AverageLatency < 10*time.Millisecond || report.P95Latency < report.AverageLatency {
+		t.Errorf("average latency %v, p95 %v", report.AverageLatency, report.P95Latency)
+	}
+	if report.Elapsed < runner.Duration {
+		t.Errorf("run took %v, want at least %v", report.Elapsed, runner.Duration)
+	}
+	want := float64(report.Successes*5) / report.Elapsed.Seconds()
+	if report.TokensPerSecond < want*0.99 || report.TokensPerSecond > want*1.01 {
+		t.Errorf("tokens/second = %.1f, want %.1f", report.TokensPerSecond, want)
+	}
+}
+
+func TestPreloadTestRunnerBacksOffAfterErrors(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	runner := &PreloadTestRunner{Client: newTestClient(srv.URL), Model: "test-model", Concurrency: 2, Duration: 300 * time.Millisecond}
+	report := runner.Run(context.Background())
+
+	// Each worker sends at most one request per backoff period
+	limit := runner.Concurrency * int(runner.Duration/preloadErrorBackoff+1)
+	if report.Errors == 0 || report.Errors > limit {
+		t.Errorf("%d failed requests in %v, want between 1 and %d", report.Errors, runner.Duration, limit)
+	}
+	if report.Successes != 0 || int(requests) < report.Errors {
+		t.Errorf("report = %+v with %d requests served", report, requests)
+	}
+}
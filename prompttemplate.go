@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// PromptData is the data available to a -system-prompt-template system prompt
+type PromptData struct {
+	Env            map[string]string
+	Now            time.Time
+	Hostname       string
+	ConversationID string
+	TurnNumber     int
+}
+
+// newPromptData captures the environment, time and hostname for a conversation
+func newPromptData(conversationID string) PromptData {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	hostname, _ := os.Hostname()
+	return PromptData{Env: env, Now: time.Now(), Hostname: hostname, ConversationID: conversationID}
+}
+
+// renderSystemPromptTemplate executes tmpl as a text/template for the given turn.
+// Unset Env keys render as empty strings.
+func renderSystemPromptTemplate(tmpl string, data PromptData, turnNumber int) (string, error) {
+	t, err := template.New("system-prompt").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parsing system prompt template: %w", err)
+	}
+	data.TurnNumber = turnNumber
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering system prompt template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// applySystemPrompt adds the -system-prompt to a new conversation. With -system-prompt-template
+// the prompt is rendered for the coming turn and replaces the leading system message of an
+// existing conversation, so fields like TurnNumber stay current.
+func applySystemPrompt(messages []openai.ChatCompletionMessageParamUnion, conversationID string) ([]openai.ChatCompletionMessageParamUnion, error) {
+	if *systemPrompt == "" {
+		return messages, nil
+	}
+	if !*systemPromptTemplate {
+		if len(messages) == 0 {
+			messages = append(messages, openai.SystemMessage(*systemPrompt))
+		}
+		return messages, nil
+	}
+
+	turn := 1
+	for _, msg := range messages {
+		if messageRole(msg) == "user" {
+			turn++
+		}
+	}
+	prompt, err := renderSystemPromptTemplate(*systemPrompt, newPromptData(conversationID), turn)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(messages) == 0:
+		messages = append(messages, openai.SystemMessage(prompt))
+	case messageRole(messages[0]) == "system":
+		messages[0] = openai.SystemMessage(prompt)
+	}
+	return messages, nil
+}
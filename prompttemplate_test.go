@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSystemPromptTemplateTurnNumber(t *testing.T) {
+	setFlag(t, systemPrompt, "Turn: {{.TurnNumber}} ({{.ConversationID}}) {{.Env.PROMPT_TEST_TEAM}}")
+	setFlag(t, systemPromptTemplate, true)
+	t.Setenv("PROMPT_TEST_TEAM", "platform")
+	gateway := newTestGateway(t, testCompletion(t, "Sunny"), testCompletion(t, "Sunny"), testCompletion(t, "Rainy"), testCompletion(t, "Rainy"))
+	client := newTestClient(gateway.URL)
+	registry := stubToolRegistry(t, "get_weather", nil)
+
+	opts := conversationOptions{Question: "Weather in Paris?", ConversationID: "conv-1"}
+	_, history, err := runConversation(context.Background(), client, registry, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = conversationOptions{Question: "And tomorrow?", History: history, ConversationID: "conv-1"}
+	if _, _, err := runConversation(context.Background(), client, registry, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each turn sends an initial and a final request
+	requests := gateway.Requests()
+	if len(requests) != 4 {
+		t.Fatalf("gateway received %d requests, want 4", len(requests))
+	}
+	for i, want := range []string{"Turn: 1 (conv-1) platform", "Turn: 2 (conv-1) platform"} {
+		messages := decodeRequestMessages(t, requests[2*i])
+		if messages[0].Role != "system" || messages[0].Content != want {
+			t.Errorf("turn %d system message = %+v, want %q", i+1, messages[0], want)
+		}
+	}
+}
+
+func TestRenderSystemPromptTemplateMissingEnv(t *testing.T) {
+	got, err := renderSystemPromptTemplate("Team: {{.Env.PROMPT_TEST_UNSET}}.", newPromptData(""), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Team: ." {
+		t.Errorf("rendered %q, want an empty value for an unset variable", got)
+	}
+	if _, err := renderSystemPromptTemplate("{{.Turn", PromptData{}, 1); err == nil {
+		t.Error("an invalid template parsed")
+	}
+}
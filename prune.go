@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// runSessionPrune implements the session-prune subcommand
+func runSessionPrune(args []string) error {
+	fs := newSubcommandFlagSet("session-prune")
+	dir := fs.String("session-dir", ".", "Directory containing session files")
+	olderThan := fs.Duration("older-than", 0, "Prune sessions started longer ago than this (e.g. 720h)")
+	dryRun := fs.Bool("dry-run", false, "Print the sessions that would be pruned without deleting them")
+	fs.Parse(args)
+
+	if *olderThan <= 0 {
+		return errors.New("session-prune: -older-than must be a positive duration")
+	}
+	store := FileConversationStore{Dir: *dir}
+
+	if *dryRun {
+		ids, err := staleSessions(store, *olderThan)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Println("Would prune", id)
+		}
+		fmt.Printf("Would prune %d sessions\n", len(ids))
+		return nil
+	}
+
+	pruned, err := pruneOldSessions(store, *olderThan)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pruned %d sessions\n", pruned)
+	return nil
+}
+
+// staleSessions returns the IDs of sessions started more than olderThan ago. Files that do
+// not load as a session with a start time, such as other JSON files in the directory, are
+// skipped rather than treated as infinitely old.
+func staleSessions(store ConversationStore, olderThan time.Duration) ([]string, error) {
+	ids, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, id := range ids {
+		session, err := store.Load(id)
+		if err != nil {
+			log.Printf("Warning: skipping %s: %v", id, err)
+			continue
+		}
+		if session.Metadata.StartedAt.IsZero() {
+			log.Printf("Warning: skipping %s: not a session file (no started_at metadata)", id)
+			continue
+		}
+		if time.Since(session.Metadata.StartedAt) > olderThan {
+			stale = append(stale, id)
+		}
+	}
+	return stale, nil
+}
+
+// pruneOldSessions deletes sessions started more than olderThan ago and returns how many were deleted
+func pruneOldSessions(store ConversationStore, olderThan time.Duration) (int, error) {
+	ids, err := staleSessions(store, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := store.Delete(id); err != nil {
+			return i, fmt.Errorf("deleting session %s: %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// memoryStore is a ConversationStore backed by a map
+type memoryStore map[string]*Session
+
+func (m memoryStore) List() ([]string, error) {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (m memoryStore) Load(id string) (*Session, error) { return m[id], nil }
+
+func (m memoryStore) Delete(id string) error {
+	delete(m, id)
+	return nil
+}
+
+func sessionStartedAgo(ago time.Duration) *Session {
+	s := newSession()
+	s.Metadata.StartedAt = time.Now().Add(-ago)
+	return s
+}
+
+func TestPruneOldSessions(t *testing.T) {
+	store := memoryStore{
+		"fresh":    sessionStartedAgo(time.Hour),
+		"month":    sessionStartedAgo(31 * 24 * time.Hour),
+		"year":     sessionStartedAgo(365 * 24 * time.Hour),
+		"week":     sessionStartedAgo(7 * 24 * time.Hour),
+		"no-start": {},
+	}
+	pruned, err := pruneOldSessions(store, 720*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d sessions, want 2", pruned)
+	}
+	remaining, _ := store.List()
+	if want := []string{"fresh", "no-start", "week"}; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("remaining sessions %v, want %v", remaining, want)
+	}
+}
+
+func TestPruneOldSessionsKeepsOtherJSONFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := sessionStartedAgo(60 * 24 * time.Hour)
+	if err := saveSession(filepath.Join(dir, "old.json"), old); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveSession(filepath.Join(dir, "new.json"), sessionStartedAgo(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	others := map[string]string{
+		"costs.json":         `{"gpt-4o":{"total_prompt_tokens":10,"request_count":1}}`,
+		"descriptions.json":  `{"get_weather":{"original":"a","enhanced":"b"}}`,
+		"not-an-object.json": `[1, 2, 3]`,
+	}
+	for name, content := range others {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := pruneOldSessions(FileConversationStore{Dir: dir}, 720*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 1 {
+		t.Errorf("pruned %d sessions, want 1", pruned)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.json")); !os.IsNotExist(err) {
+		t.Error("old session was not deleted")
+	}
+	for _, name := range []string{"new.json", "costs.json", "descriptions.json", "not-an-object.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was deleted: %v", name, err)
+		}
+	}
+}
+
+func TestFileConversationStoreDeleteWaitsForLock(t *testing.T) {
+	setFlag(t, conversationLock, true)
+	setFlag(t, lockTimeout, 100*time.Millisecond)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "held.json")
+	if err := saveSession(path, newSession()); err != nil {
+		t.Fatal(err)
+	}
+	lock := &FileLock{Path: path + ".lock"}
+	if err := lock.Lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	if err := (FileConversationStore{Dir: dir}).Delete("held"); err == nil {
+		t.Error("Delete succeeded while another holder had the session lock")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("locked session was deleted: %v", err)
+	}
+}
+
+func TestRunSessionPruneConversationLock(t *testing.T) {
+	setFlag(t, conversationLock, false)
+	setFlag(t, lockTimeout, *lockTimeout)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "held.json")
+	if err := saveSession(path, sessionStartedAgo(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	lock := &FileLock{Path: path + ".lock"}
+	if err := lock.Lock(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The held lock times out loading the session, so it is skipped
+	args := []string{"-session-dir", dir, "-older-than", "24h", "-conversation-lock", "-lock-timeout", "100ms"}
+	if err := runSessionPrune(args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("locked session was deleted: %v", err)
+	}
+
+	lock.Unlock()
+	if err := runSessionPrune(args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("session was not pruned once its lock was released")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ragChunkSize is the length of each corpus chunk in characters
+	ragChunkSize = 500
+	// ragChunkOverlap is how many characters consecutive chunks share
+	ragChunkOverlap = 100
+	// ragTopK is the number of chunks injected before each request
+	ragTopK = 3
+)
+
+// ragIndex is the corpus index built from -rag-corpus-dir, nil when RAG is disabled
+var ragIndex *TFIDFIndex
+
+// Document is a chunk of a corpus file
+type Document struct {
+	Source     string `json:"source"`
+	ChunkIndex int    `json:"chunk_index"`
+	Text       string `json:"text"`
+}
+
+// TFIDFIndex ranks documents against a query by cosine similarity of TF-IDF vectors
+type TFIDFIndex struct {
+	docs    []Document
+	vectors []map[string]float64
+	idf     map[string]float64
+}
+
+// Build indexes docs, replacing any previous contents
+func (idx *TFIDFIndex) Build(docs []Document) error {
+	if len(docs) == 0 {
+		return errors.New("no documents to index")
+	}
+	termCounts := make([]map[string]int, len(docs))
+	docFreq := map[string]int{}
+	for i, doc := range docs {
+		counts := map[string]int{}
+		for _, term := range tokenize(doc.Text) {
+			counts[term]++
+		}
+		for term := range counts {
+			docFreq[term]++
+		}
+		termCounts[i] = counts
+	}
+
+	n := float64(len(docs))
+	idx.idf = make(map[string]float64, len(docFreq))
+	for term, df := range docFreq {
+		idx.idf[term] = math.Log((n+1)/(float64(df)+1)) + 1
+	}
+	idx.docs = docs
+	idx.vectors = make([]map[string]float64, len(docs))
+	for i, counts := range termCounts {
+		idx.vectors[i] = idx.weigh(counts)
+	}
+	return nil
+}
+
+// Search returns up to k documents most similar to query, best first
+func (idx *TFIDFIndex) Search(query string, k int) []Document {
+	counts := map[string]int{}
+	for _, term := range tokenize(query) {
+		if _, known := idx.idf[term]; known {
+			counts[term]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	queryVec := idx.weigh(counts)
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var results []scored
+	for i, vec := range idx.vectors {
+		var score float64
+		for term, w := range queryVec {
+			score += w * vec[term]
+		}
+		if score > 0 {
+			results = append(results, scored{i, score})
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].score > results[b].score })
+	if len(results) > k {
+		results = results[:k]
+	}
+	docs := make([]Document, 0, len(results))
+	for _, r := range results {
+		docs = append(docs, idx.docs[r.index])
+	}
+	return docs
+}
+
+// weigh turns term counts into a unit-length TF-IDF vector
+func (idx *TFIDFIndex) weigh(counts map[string]int) map[string]float64 {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	vec := make(map[string]float64, len(counts))
+	var norm float64
+	for term, c := range counts {
+		w := float64(c) / float64(total) * idx.idf[term]
+		vec[term] = w
+		norm += w * w
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		for term := range vec {
+			vec[term] /= norm
+		}
+	}
+	return vec
+}
+
+// tokenize lowercases text and splits it into letter and digit runs
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// loadRAGCorpus chunks every .txt file under dir into overlapping documents
+func loadRAGCorpus(dir string) ([]Document, error) {
+	var docs []Document
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".txt" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for i, chunk := range chunkText(string(data), ragChunkSize, ragChunkOverlap) {
+			docs = append(docs, Document{Source: path, ChunkIndex: i, Text: chunk})
+		}
+		return nil
+	})
+	return docs, err
+}
+
+// chunkText splits text into chunks of size characters, each sharing overlap characters with the previous one
+func chunkText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) == 0 {
+		return nil
+	}
+	step := size - overlap
+	if step <= 0 {
+		step = size
+	}
+	var chunks []string
+	for start := 0; start < len(runes); start += step {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
+
+// formatRAGContext renders retrieved chunks as context for the model
+func formatRAGContext(docs []Document) string {
+	if len(docs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Use the following context to answer the question if it is relevant.\n")
+	for _, doc := range docs {
+		fmt.Fprintf(&b, "\n[%s#%d]\n%s\n", doc.Source, doc.ChunkIndex, doc.Text)
+	}
+	return b.String()
+}
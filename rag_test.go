@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTFIDFIndexSearch(t *testing.T) {
+	docs := []Document{
+		{Source: "cooking.txt", Text: "Simmer the tomato sauce and season the pasta with basil."},
+		{Source: "weather.txt", Text: "The weather forecast for New York: rain in the morning, sunny weather later."},
+		{Source: "gateway.txt", Text: "The AI gateway routes requests to model providers and applies rate limits."},
+	}
+	var idx TFIDFIndex
+	if err := idx.Build(docs); err != nil {
+		t.Fatal(err)
+	}
+	got := idx.Search("What is the weather in New York?", 2)
+	if len(got) == 0 || got[0].Source != "weather.txt" {
+		t.Fatalf("Search ranked %v first, want weather.txt", got)
+	}
+	if len(got) > 2 {
+		t.Errorf("Search returned %d documents, want at most 2", len(got))
+	}
+	if got := idx.Search("quantum chromodynamics", 3); len(got) != 0 {
+		t.Errorf("query with unknown terms returned %v", got)
+	}
+	if err := idx.Build(nil); err == nil {
+		t.Error("Build with no documents succeeded")
+	}
+}
+
+func TestChunkTextOverlap(t *testing.T) {
+	text := strings.Repeat("abcdefghij", 120)
+	chunks := chunkText(text, ragChunkSize, ragChunkOverlap)
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	for i := 1; i < len(chunks); i++ {
+		prev := chunks[i-1]
+		if !strings.HasPrefix(chunks[i], prev[len(prev)-ragChunkOverlap:]) {
+			t.Errorf("chunk %d does not overlap the previous chunk by %d characters", i, ragChunkOverlap)
+		}
+	}
+}
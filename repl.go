@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	openai "github.com/openai/openai-go"
+	"golang.org/x/term"
+)
+
+// replPrompt is shown before each question in REPL mode
+const replPrompt = "> "
+
+// errInterrupted is returned by the line editor on Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// runREPL asks questions read from stdin until EOF or "exit", continuing the session
+// across turns and saving it after each one
+func runREPL(ctx context.Context, client *openai.Client, registry *ToolRegistry, session *Session) error {
+	var history *PromptHistory
+	if *promptHistory {
+		var err error
+		if history, err = loadPromptHistory(*promptHistoryFile); err != nil {
+			return fmt.Errorf("loading prompt history: %w", err)
+		}
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	fd := int(os.Stdin.Fd())
+	rawInput := history != nil && term.IsTerminal(fd)
+	for {
+		var line string
+		var err error
+		if rawInput {
+			line, err = readLineWithHistory(fd, in, os.Stdout, history)
+		} else {
+			fmt.Print(replPrompt)
+			line, err = in.ReadString('\n')
+			if err == io.EOF && line != "" {
+				err = nil
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, errInterrupted) {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			return nil
+		}
+		if history != nil {
+			history.Add(line)
+			if err := savePromptHistory(*promptHistoryFile, history); err != nil {
+				log.Printf("Warning: failed to save prompt history: %v", err)
+			}
+		}
+
+		question := maybeRewriteQuestion(ctx, client, line)
+		responseText, messages, err := runConversation(ctx, client, registry, conversationOptions{Question: question, History: session.Messages, ConversationID: session.Metadata.ID})
+		if errors.Is(err, errStoppedAfterTools) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			continue
+		}
+		session.Messages = messages
+		applySessionTitle(session, responseText)
+		persistSession(session)
+		responseText = finalizeResponse(responseText)
+		recordAudit(session, question, responseText)
+		fmt.Println(responseText)
+	}
+}
+
+// readLineWithHistory reads one line with the terminal in raw mode, using the up and
+// down arrows to step through history
+func readLineWithHistory(fd int, in *bufio.Reader, out io.Writer, history *PromptHistory) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	var line []rune
+	redraw := func() {
+		fmt.Fprintf(out, "\r\033[K%s%s", replPrompt, string(line))
+	}
+	redraw()
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(out, "\r\n")
+			return string(line), nil
+		case 3: // Ctrl-C
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+		case 127, '\b':
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				redraw()
+			}
+		case 27: // escape sequence, only arrow keys are handled
+			if b, err := in.ReadByte(); err != nil || b != '[' {
+				continue
+			}
+			b, err := in.ReadByte()
+			if err != nil {
+				continue
+			}
+			switch b {
+			case 'A':
+				line = []rune(history.Previous())
+			case 'B':
+				line = []rune(history.Next())
+			default:
+				continue
+			}
+			redraw()
+		default:
+			if unicode.IsPrint(r) {
+				line = append(line, r)
+				fmt.Fprint(out, string(r))
+			}
+		}
+	}
+}
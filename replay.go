@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// replayUserDelay is the pause before each user message at speed 1.0
+	replayUserDelay = 200 * time.Millisecond
+	// replayCharsPerSecond is the assistant typing speed at speed 1.0
+	replayCharsPerSecond = 50
+)
+
+// AnnotatedMessage is a saved message prepared for replay
+type AnnotatedMessage struct {
+	Role    string
+	Content string
+	// DurationMs is how long the original turn took, when recorded
+	DurationMs int64
+}
+
+// ConversationReplayer re-prints a saved conversation with presentation timing
+type ConversationReplayer struct {
+	Messages []AnnotatedMessage
+	Speed    float64
+}
+
+// runReplay implements the replay subcommand
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	path := fs.String("session-file", "", "Session file to replay")
+	speed := fs.Float64("speed", 1.0, "Replay speed factor (2.0 halves the pauses)")
+	filterRoles := fs.String("replay-filter-roles", "", "Comma-separated roles to skip during replay, e.g. tool")
+	startTurn := fs.Int("replay-start-turn", 0, "First message to replay, counted from 1 (0 starts at the beginning)")
+	endTurn := fs.Int("replay-end-turn", 0, "Last message to replay, counted from 1 and inclusive (0 replays to the end)")
+	fs.Parse(args)
+
+	if *path == "" {
+		return errors.New("replay: -session-file is required")
+	}
+	if *speed <= 0 {
+		return errors.New("replay: -speed must be positive")
+	}
+	if *startTurn < 0 || *endTurn < 0 || (*endTurn > 0 && *endTurn < *startTurn) {
+		return errors.New("replay: -replay-start-turn and -replay-end-turn must form a valid range")
+	}
+	messages, err := loadAnnotatedMessages(*path)
+	if err != nil {
+		return err
+	}
+	messages = replayRange(messages, *startTurn, *endTurn)
+	messages = filteredReplay(messages, splitCommaList(*filterRoles))
+	replayer := ConversationReplayer{Messages: messages, Speed: *speed}
+	return replayer.Play(context.Background(), os.Stdout)
+}
+
+// loadAnnotatedMessages reads a session file into replayable messages
+func loadAnnotatedMessages(path string) ([]AnnotatedMessage, error) {
+	data, err := readSessionFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing session %s: %w", path, err)
+	}
+	if err := upgradeMessages(file.Messages, file.Metadata.Version); err != nil {
+		return nil, err
+	}
+
+	messages := make([]AnnotatedMessage, 0, len(file.Messages))
+	for _, m := range file.Messages {
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		var msg struct {
+			sessionMessage
+			DurationMs int64 `json:"duration_ms"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		content := contentText(msg.Content)
+		if calls := formatToolCalls(msg.ToolCalls); calls != "" {
+			content = strings.TrimSpace(content + "\n" + calls)
+		}
+		messages = append(messages, AnnotatedMessage{Role: msg.Role, Content: content, DurationMs: msg.DurationMs})
+	}
+	return messages, nil
+}
+
+// replayRange returns messages start through end, both counted from 1 and inclusive.
+// A zero start or end leaves that side of the range open.
+func replayRange(messages []AnnotatedMessage, start, end int) []AnnotatedMessage {
+	if end == 0 || end > len(messages) {
+		end = len(messages)
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start > end {
+		return nil
+	}
+	return messages[start-1 : end]
+}
+
+// filteredReplay drops messages whose role is in excludeRoles
+func filteredReplay(messages []AnnotatedMessage, excludeRoles []string) []AnnotatedMessage {
+	if len(excludeRoles) == 0 {
+		return messages
+	}
+	excluded := map[string]bool{}
+	for _, role := range excludeRoles {
+		excluded[role] = true
+	}
+	kept := make([]AnnotatedMessage, 0, len(messages))
+	for _, msg := range messages {
+		if !excluded[msg.Role] {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+// formatToolCalls renders saved tool calls as name(arguments) lines
+func formatToolCalls(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var calls []struct {
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &calls); err != nil {
+		return ""
+	}
+	lines := make([]string, 0, len(calls))
+	for _, call := range calls {
+		lines = append(lines, fmt.Sprintf("[tool call] %s(%s)", call.Function.Name, call.Function.Arguments))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Play writes each message to w. System messages are printed instantly, user messages after
+// a short fixed delay, and assistant messages are typed out character by character.
+// Messages with a recorded duration are preceded by a proportional pause.
+func (r ConversationReplayer) Play(ctx context.Context, w io.Writer) error {
+	speed := r.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) / speed)
+	}
+
+	for _, msg := range r.Messages {
+		if msg.Role != "system" && msg.DurationMs > 0 {
+			if err := sleepContext(ctx, scale(time.Duration(msg.DurationMs)*time.Millisecond)); err != nil {
+				return err
+			}
+		}
+
+		switch msg.Role {
+		case "system":
+			fmt.Fprintf(w, "%s: %s\n", msg.Role, msg.Content)
+		case "user":
+			if err := sleepContext(ctx, scale(replayUserDelay)); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s: %s\n", msg.Role, msg.Content)
+		case "assistant":
+			fmt.Fprintf(w, "%s: ", msg.Role)
+			charDelay := scale(time.Second / replayCharsPerSecond)
+			for _, c := range msg.Content {
+				if err := sleepContext(ctx, charDelay); err != nil {
+					return err
+				}
+				fmt.Fprint(w, string(c))
+			}
+			fmt.Fprintln(w)
+		default:
+			fmt.Fprintf(w, "%s: %s\n", msg.Role, msg.Content)
+		}
+	}
+	return nil
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestReplayTiming(t *testing.T) {
+	messages := []AnnotatedMessage{
+		{Role: "system", Content: "You are a helpful assistant."},
+		{Role: "user", Content: "Weather?"},
+		{Role: "assistant", Content: "Sunny", DurationMs: 100},
+	}
+	// 200ms user delay, 100ms recorded pause and 5 chars at 50 chars/s
+	tests := []struct {
+		speed float64
+		want  time.Duration
+	}{
+		{1.0, 400 * time.Millisecond},
+		{2.0, 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		replayer := ConversationReplayer{Messages: messages, Speed: tt.speed}
+		start := time.Now()
+		if err := replayer.Play(context.Background(), &out); err != nil {
+			t.Fatal(err)
+		}
+		elapsed := time.Since(start)
+		if elapsed < tt.want || elapsed > tt.want+150*time.Millisecond {
+			t.Errorf("speed %v: replay took %v, want about %v", tt.speed, elapsed, tt.want)
+		}
+		want := "system: You are a helpful assistant.\nuser: Weather?\nassistant: Sunny\n"
+		if out.String() != want {
+			t.Errorf("speed %v: output %q, want %q", tt.speed, out.String(), want)
+		}
+	}
+}
+
+func TestReplayCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	replayer := ConversationReplayer{Messages: []AnnotatedMessage{{Role: "user", Content: "Weather?"}}, Speed: 1}
+	if err := replayer.Play(ctx, &bytes.Buffer{}); err == nil {
+		t.Error("a cancelled replay returned no error")
+	}
+}
+
+// testReplayMessages is a 6-message session with a tool round trip
+var testReplayMessages = []AnnotatedMessage{
+	{Role: "system", Content: "You are a helpful assistant."},
+	{Role: "user", Content: "Weather in Paris?"},
+	{Role: "assistant", Content: `[tool call] get_weather({"city":"Paris"})`},
+	{Role: "tool", Content: "Sunny, 25°C"},
+	{Role: "tool", Content: "Humidity 40%"},
+	{Role: "assistant", Content: "It is sunny in Paris."},
+}
+
+func TestFilteredReplay(t *testing.T) {
+	got := filteredReplay(testReplayMessages, splitCommaList("tool"))
+	if len(got) != 4 {
+		t.Fatalf("filtering tool kept %d messages, want 4", len(got))
+	}
+	for _, msg := range got {
+		if msg.Role == "tool" {
+			t.Errorf("tool message kept: %q", msg.Content)
+		}
+	}
+	if got := filteredReplay(testReplayMessages, nil); len(got) != len(testReplayMessages) {
+		t.Errorf("no filter kept %d messages, want %d", len(got), len(testReplayMessages))
+	}
+}
+
+func TestReplayRange(t *testing.T) {
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{2, 4, "user|assistant|tool"},
+		{0, 0, "system|user|assistant|tool|tool|assistant"},
+		{5, 0, "tool|assistant"},
+		{0, 2, "system|user"},
+		{4, 100, "tool|tool|assistant"},
+		{7, 0, ""},
+	}
+	for _, tt := range tests {
+		var roles []string
+		for _, msg := range replayRange(testReplayMessages, tt.start, tt.end) {
+			roles = append(roles, msg.Role)
+		}
+		if got := strings.Join(roles, "|"); got != tt.want {
+			t.Errorf("replayRange(%d, %d) = %s, want %s", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestRunReplayFlags(t *testing.T) {
+	session := newSession()
+	session.Messages = []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are a helpful assistant."),
+		openai.UserMessage("Weather in Paris?"),
+		openai.ToolMessage("call_1", "Sunny, 25°C"),
+		openai.ToolMessage("call_2", "Humidity 40%"),
+		openai.AssistantMessage("Sunny."),
+		openai.UserMessage("Thanks"),
+	}
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := saveSession(path, session); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-replay-start-turn", "2", "-replay-end-turn", "4"},
+			"user: Weather in Paris?\ntool: Sunny, 25°C\ntool: Humidity 40%\n"},
+		{[]string{"-replay-filter-roles", "tool,system"},
+			"user: Weather in Paris?\nassistant: Sunny.\nuser: Thanks\n"},
+	}
+	for _, tt := range tests {
+		args := append([]string{"-session-file", path, "-speed", "1000"}, tt.args...)
+		out := captureStdout(t, func() {
+			if err := runReplay(args); err != nil {
+				t.Error(err)
+			}
+		})
+		if out != tt.want {
+			t.Errorf("replay %q printed %q, want %q", tt.args, out, tt.want)
+		}
+	}
+	if err := runReplay([]string{"-session-file", path, "-replay-start-turn", "4", "-replay-end-turn", "2"}); err == nil {
+		t.Error("an inverted turn range was accepted")
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// defaultResponseCacheDir is where cache-invalidate looks when -cache-dir is not given
+const defaultResponseCacheDir = ".response-cache"
+
+// responseCacheFileExt is the extension of ResponseStore entries
+const responseCacheFileExt = ".json"
+
+// ResponseCacheEntry is a cached completion together with the request that produced it
+type ResponseCacheEntry struct {
+	Model     string          `json:"model"`
+	Params    json.RawMessage `json:"params"`
+	Response  json.RawMessage `json:"response"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ResponseStore caches completions in Dir, one <key>.json file per request
+type ResponseStore struct {
+	Dir string
+}
+
+// responseStore is set from -response-cache-dir
+var responseStore *ResponseStore
+
+// responseCacheKey hashes the complete serialized request, so that requests differing in
+// tools, sampling parameters or anything else besides the messages are cached separately
+func responseCacheKey(params openai.ChatCompletionNewParams) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get returns the cached completion for key, if any
+func (s *ResponseStore) Get(key string) (*openai.ChatCompletion, bool) {
+	entry, err := s.Load(key)
+	if err != nil {
+		return nil, false
+	}
+	var resp openai.ChatCompletion
+	if err := json.Unmarshal(entry.Response, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// Put stores resp as the cached completion for params under key
+func (s *ResponseStore) Put(key string, params openai.ChatCompletionNewParams, resp *openai.ChatCompletion) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	rawResponse := json.RawMessage(resp.JSON.RawJSON())
+	if len(rawResponse) == 0 {
+		if rawResponse, err = json.Marshal(resp); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(ResponseCacheEntry{
+		Model:     params.Model.Value,
+		Params:    rawParams,
+		Response:  rawResponse,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(key), data, 0o600)
+}
+
+// List returns the keys of all cached entries, sorted
+func (s *ResponseStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), responseCacheFileExt) {
+			keys = append(keys, strings.TrimSuffix(entry.Name(), responseCacheFileExt))
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Load reads the entry stored under key
+func (s *ResponseStore) Load(key string) (*ResponseCacheEntry, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, err
+	}
+	var entry ResponseCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("parsing cache entry %s: %w", key, err)
+	}
+	return &entry, nil
+}
+
+// Delete removes the entry stored under key
+func (s *ResponseStore) Delete(key string) error {
+	return os.Remove(s.path(key))
+}
+
+func (s *ResponseStore) path(key string) string {
+	return filepath.Join(s.Dir, key+responseCacheFileExt)
+}
+
+// firstUserMessage returns the text of the first user message in the cached params
+func (e *ResponseCacheEntry) firstUserMessage() (string, error) {
+	var params struct {
+		Messages []sessionMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(e.Params, &params); err != nil {
+		return "", err
+	}
+	for _, msg := range params.Messages {
+		if msg.Role == string(openai.ChatCompletionMessageParamRoleUser) {
+			return contentText(msg.Content), nil
+		}
+	}
+	return "", errors.New("no user message")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestResponseStoreCachesFullRequest(t *testing.T) {
+	setFlag(t, &responseStore, &ResponseStore{Dir: t.TempDir()})
+	gateway := newTestGateway(t, testCompletion(t, "first"), testCompletion(t, "second"))
+	client := newTestClient(gateway.URL)
+
+	params := questionParams("test-model", "What is the weather in New York?")
+	withTools := params
+	withTools.Tools = openai.F([]openai.ChatCompletionToolParam{{
+		Type: openai.F(openai.ChatCompletionToolTypeFunction),
+		Function: openai.F(openai.FunctionDefinitionParam{
+			Name:       openai.String("get_weather"),
+			Parameters: openai.F(openai.FunctionParameters{"type": "object"}),
+		}),
+	}})
+
+	steps := []struct {
+		params       openai.ChatCompletionNewParams
+		wantContent  string
+		wantRequests int
+	}{
+		{params, "first", 1},
+		{params, "first", 1},
+		{withTools, "second", 2},
+		{withTools, "second", 2},
+	}
+	for i, step := range steps {
+		resp, err := createCompletion(context.Background(), client, step.params)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if resp.Choices[0].Message.Content != step.wantContent {
+			t.Errorf("step %d: content %q, want %q", i, resp.Choices[0].Message.Content, step.wantContent)
+		}
+		if got := len(gateway.Requests()); got != step.wantRequests {
+			t.Errorf("step %d: gateway received %d requests, want %d", i, got, step.wantRequests)
+		}
+	}
+}
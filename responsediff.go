@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// wordPattern splits text into words and the whitespace runs between them, so a word
+// matches itself whatever follows it
+var wordPattern = regexp.MustCompile(`\S+|\s+`)
+
+// diffWords returns a word-level diff of prev and current showing only the changed
+// portions, one per line, prefixed with "- " for removals and "+ " for additions
+func diffWords(prev, current string) string {
+	// Map each distinct word to a rune so the diff runs word by word
+	wordIndex := map[string]rune{}
+	var words []string
+	encode := func(text string) []rune {
+		tokens := wordPattern.FindAllString(text, -1)
+		runes := make([]rune, len(tokens))
+		for i, token := range tokens {
+			r, ok := wordIndex[token]
+			if !ok {
+				r = rune(len(words))
+				wordIndex[token] = r
+				words = append(words, token)
+			}
+			runes[i] = r
+		}
+		return runes
+	}
+	prevRunes, currentRunes := encode(prev), encode(current)
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(prevRunes, currentRunes, false)
+
+	var b strings.Builder
+	for _, d := range diffs {
+		var prefix string
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "- "
+		case diffmatchpatch.DiffInsert:
+			prefix = "+ "
+		default:
+			continue
+		}
+		var text strings.Builder
+		for _, r := range d.Text {
+			text.WriteString(words[r])
+		}
+		if changed := strings.TrimSpace(text.String()); changed != "" {
+			b.WriteString(prefix + changed + "\n")
+		}
+	}
+	return b.String()
+}
+
+// diffFromLastResponse diffs text against the response saved in path and saves text for the next run.
+// It returns the full text when there is no previous response.
+func diffFromLastResponse(path, text string) (string, error) {
+	output := text
+	prev, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		output = diffWords(string(prev), text)
+		if output == "" {
+			output = "(no changes since last response)"
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		return "", err
+	}
+	return output, nil
+}
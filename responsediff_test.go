@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffFromLastResponse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last-response.txt")
+	first := "It is sunny in New York. The temperature is 25°C. No rain is expected."
+	got, err := diffFromLastResponse(path, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != first {
+		t.Errorf("first run printed %q, want the full response", got)
+	}
+
+	second := "It is sunny in New York. The temperature is 18°C. No rain is expected."
+	got, err = diffFromLastResponse(path, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- 25°C.\n+ 18°C.\n"; got != want {
+		t.Errorf("diff = %q, want %q", got, want)
+	}
+
+	got, err = diffFromLastResponse(path, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "(no changes since last response)" {
+		t.Errorf("unchanged response printed %q", got)
+	}
+}
+
+func TestDiffWordsAddedSentence(t *testing.T) {
+	got := diffWords("It is sunny.", "It is sunny. Bring sunglasses.")
+	if want := "+ Bring sunglasses.\n"; got != want {
+		t.Errorf("diffWords = %q, want %q", got, want)
+	}
+}
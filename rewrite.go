@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+const rewritePromptPrefix = "Rewrite the following question to be clearer and more specific, preserving intent: "
+
+// rewriteQuestion asks the model to rephrase the question before it is answered
+func rewriteQuestion(ctx context.Context, client *openai.Client, model, question string) (string, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(rewritePromptPrefix + question),
+		}),
+		Model:     openai.F(model),
+		MaxTokens: openai.Int(200),
+	}
+	resp, err := sendRequest(ctx, client, params)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("rewrite response has no choices")
+	}
+	rewritten := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if rewritten == "" {
+		return "", errors.New("rewrite response is empty")
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQuestionRewriteIsUsedForTheMainRequest(t *testing.T) {
+	setFlag(t, questionRewrite, true)
+	const rewritten = "What is the current temperature and sky condition in New York City?"
+	gateway := newTestGateway(t,
+		testCompletion(t, "  "+rewritten+"\n"),
+		testCompletion(t, "checking"),
+		testCompletion(t, "Sunny"),
+	)
+	client := newTestClient(gateway.URL)
+
+	question := maybeRewriteQuestion(context.Background(), client, "weather nyc?")
+	if question != rewritten {
+		t.Fatalf("rewritten question = %q, want %q", question, rewritten)
+	}
+	if _, _, err := runConversation(context.Background(), client, stubToolRegistry(t, "noop", nil), conversationOptions{Question: question}); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := gateway.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("gateway got %d requests, want 3", len(requests))
+	}
+	rewrite := decodeRequestMessages(t, requests[0])
+	if len(rewrite) != 1 || rewrite[0].Content != rewritePromptPrefix+"weather nyc?" {
+		t.Errorf("rewrite request messages = %+v", rewrite)
+	}
+	if !strings.Contains(string(requests[0]), `"max_tokens":200`) {
+		t.Errorf("rewrite request does not cap max_tokens at 200: %s", requests[0])
+	}
+	main := decodeRequestMessages(t, requests[1])
+	if last := main[len(main)-1]; last.Role != "user" || last.Content != rewritten {
+		t.Errorf("main request ends with %+v, want the rewritten question", last)
+	}
+}
+
+func TestQuestionRewriteFallsBackOnError(t *testing.T) {
+	setFlag(t, questionRewrite, true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	if got := maybeRewriteQuestion(context.Background(), newTestClient(srv.URL), "weather nyc?"); got != "weather nyc?" {
+		t.Errorf("got %q, want the original question", got)
+	}
+}
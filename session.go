@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// ConversationMetadata describes a saved conversation
+type ConversationMetadata struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	Title     string    `json:"title,omitempty"`
+	// Version is the session file schema version, see migrateSession
+	Version int `json:"version"`
+}
+
+// Session is the on-disk form of a conversation
+type Session struct {
+	Metadata ConversationMetadata                     `json:"metadata"`
+	Messages []openai.ChatCompletionMessageParamUnion `json:"messages"`
+}
+
+// sessionMessage is the wire form of a saved message, used to pick a concrete param type on load
+type sessionMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolCalls  json.RawMessage `json:"tool_calls,omitempty"`
+}
+
+// newSession starts an empty session with a fresh ID
+func newSession() *Session {
+	return &Session{
+		Metadata: ConversationMetadata{
+			ID:        newSessionID(),
+			StartedAt: time.Now().UTC(),
+			Version:   currentSessionVersion,
+		},
+	}
+}
+
+// newSessionID returns a random 16 character hex ID
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// MarshalJSON writes the messages in the current session schema version
+func (s Session) MarshalJSON() ([]byte, error) {
+	messages := make([]map[string]interface{}, 0, len(s.Messages))
+	for _, msg := range s.Messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := upgradeMessages(messages, 0); err != nil {
+		return nil, err
+	}
+
+	metadata := s.Metadata
+	metadata.Version = currentSessionVersion
+	return json.Marshal(struct {
+		Metadata ConversationMetadata     `json:"metadata"`
+		Messages []map[string]interface{} `json:"messages"`
+	}{metadata, messages})
+}
+
+// UnmarshalJSON migrates the saved messages to the current schema and decodes them into message params
+func (s *Session) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Metadata ConversationMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	messages, err := migrateSession(data)
+	if err != nil {
+		return err
+	}
+	s.Metadata = raw.Metadata
+	s.Metadata.Version = currentSessionVersion
+	s.Messages = messages
+	return nil
+}
+
+// decodeMessages converts raw saved messages back into message params
+func decodeMessages(raw []json.RawMessage) ([]openai.ChatCompletionMessageParamUnion, error) {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(raw))
+	for i, r := range raw {
+		msg, err := decodeMessage(r)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// decodeMessage converts a single raw message into a message param
+func decodeMessage(raw json.RawMessage) (openai.ChatCompletionMessageParamUnion, error) {
+	var m sessionMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	if m.Role == "" {
+		return nil, errors.New("missing role")
+	}
+	if m.Role == string(openai.ChatCompletionMessageRoleAssistant) {
+		var toolCalls []openai.ChatCompletionMessageToolCall
+		if len(m.ToolCalls) > 0 {
+			if err := json.Unmarshal(m.ToolCalls, &toolCalls); err != nil {
+				return nil, err
+			}
+		}
+		return openai.ChatCompletionMessage{
+			Role:      openai.ChatCompletionMessageRoleAssistant,
+			Content:   contentText(m.Content),
+			ToolCalls: toolCalls,
+		}, nil
+	}
+
+	msg := openai.ChatCompletionMessageParam{
+		Role: openai.F(openai.ChatCompletionMessageParamRole(m.Role)),
+	}
+	if len(m.Content) > 0 {
+		var content interface{}
+		if err := json.Unmarshal(m.Content, &content); err != nil {
+			return nil, err
+		}
+		msg.Content = openai.F(content)
+	}
+	if m.Name != "" {
+		msg.Name = openai.F(m.Name)
+	}
+	if m.ToolCallID != "" {
+		msg.ToolCallID = openai.F(m.ToolCallID)
+	}
+	return msg, nil
+}
+
+// gzipMagic is the two-byte header of gzip streams
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readSessionFile returns the JSON contents of a session file, decompressing it
+// when it starts with the gzip header
+func readSessionFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing session %s: %w", path, err)
+		}
+		defer zr.Close()
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("decompressing session %s: %w", path, err)
+		}
+	}
+	return data, nil
+}
+
+// loadSession reads a session file
+func loadSession(path string) (*Session, error) {
+	unlock, err := lockSession(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	data, err := readSessionFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("parsing session %s: %w", path, err)
+	}
+	return &session, nil
+}
+
+// loadOrCreateSession loads the session at path, or starts a new one when the file does not exist
+func loadOrCreateSession(path string) (*Session, error) {
+	session, err := loadSession(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newSession(), nil
+	}
+	return session, err
+}
+
+// saveSession writes the session as indented JSON
+func saveSession(path string, session *Session) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeSessionFile(path, data)
+}
+
+// saveSessionGzipped writes the session as gzip-compressed JSON
+func saveSessionGzipped(path string, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return writeSessionFile(path, buf.Bytes())
+}
+
+// writeSessionFile writes data to path while holding the -conversation-lock lock
+func writeSessionFile(path string, data []byte) error {
+	unlock, err := lockSession(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return os.WriteFile(path, data, 0o600)
+}
+
+// compressedSessionPath appends the .gz suffix to path unless it is already there
+func compressedSessionPath(path string) string {
+	if strings.HasSuffix(path, ".gz") {
+		return path
+	}
+	return path + ".gz"
+}
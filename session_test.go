@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+// testHistory is a small conversation with a tool round trip. Assistant turns are
+// ChatCompletionMessage values, as runConversation stores them.
+func testHistory() []openai.ChatCompletionMessageParamUnion {
+	toolCall := testToolCall("call_1", "get_weather", `{"location":"New York City"}`)
+	return []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are a weather bot."),
+		openai.UserMessage("What is the weather in New York City?"),
+		openai.ChatCompletionMessage{Role: openai.ChatCompletionMessageRoleAssistant, ToolCalls: []openai.ChatCompletionMessageToolCall{toolCall}},
+		openai.ToolMessage("call_1", "Sunny, 25°C"),
+		openai.ChatCompletionMessage{Role: openai.ChatCompletionMessageRoleAssistant, Content: "It is sunny and 25°C in New York City."},
+	}
+}
+
+func TestSaveSessionGzippedRoundTrip(t *testing.T) {
+	session := newSession()
+	session.Messages = testHistory()
+	path := compressedSessionPath(filepath.Join(t.TempDir(), "session.json"))
+	if filepath.Ext(path) != ".gz" {
+		t.Fatalf("compressed path %s has no .gz suffix", path)
+	}
+	if err := saveSessionGzipped(path, session); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(raw, gzipMagic) {
+		t.Fatal("saved file is not gzip-compressed")
+	}
+
+	loaded, err := loadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(session.Messages)
+	got, _ := json.Marshal(loaded.Messages)
+	if !bytes.Equal(got, want) {
+		t.Errorf("messages changed in the round trip:\n got %s\nwant %s", got, want)
+	}
+	if loaded.Metadata.ID != session.Metadata.ID {
+		t.Errorf("metadata ID = %q, want %q", loaded.Metadata.ID, session.Metadata.ID)
+	}
+}
+
+func TestLoadSessionReadsUncompressedFiles(t *testing.T) {
+	session := newSession()
+	session.Messages = testHistory()
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := saveSession(path, session); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Messages) != len(session.Messages) {
+		t.Errorf("loaded %d messages, want %d", len(loaded.Messages), len(session.Messages))
+	}
+}
+
+func TestCompressedSessionPath(t *testing.T) {
+	for in, want := range map[string]string{"s.json": "s.json.gz", "s.json.gz": "s.json.gz"} {
+		if got := compressedSessionPath(in); got != want {
+			t.Errorf("compressedSessionPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSignV4KnownVectors checks signV4 against the get-vanilla cases of the AWS Signature
+// Version 4 test suite
+func TestSignV4KnownVectors(t *testing.T) {
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			"get-vanilla",
+			"https://example.amazonaws.com/",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			"get-vanilla-query-order-key-case",
+			"https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signV4(req, nil, creds, "us-east-1", "service", now)
+		if got := req.Header.Get("Authorization"); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+		if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+			t.Errorf("%s: X-Amz-Date = %q", tt.name, got)
+		}
+	}
+}
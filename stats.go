@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+// Stats summarizes a conversation. Turns are numbered from 1; each starts at a user message.
+type Stats struct {
+	TotalTurns               int      `json:"total_turns"`
+	UserWords                int      `json:"user_words"`
+	AssistantWords           int      `json:"assistant_words"`
+	UniqueTools              []string `json:"unique_tools"`
+	AverageResponseLength    float64  `json:"average_response_length"`
+	LongestTurn              int      `json:"longest_turn"`
+	LongestTurnChars         int      `json:"longest_turn_chars"`
+	MostVerboseUserTurn      int      `json:"most_verbose_user_turn"`
+	MostVerboseUserTurnChars int      `json:"most_verbose_user_turn_chars"`
+}
+
+// ConversationStatistics computes Stats for a message history
+type ConversationStatistics struct{}
+
+// Compute walks the messages once and returns their statistics
+func (ConversationStatistics) Compute(messages []openai.ChatCompletionMessageParamUnion) Stats {
+	var stats Stats
+	tools := map[string]bool{}
+	responses, responseChars := 0, 0
+	turnChars := 0
+
+	endTurn := func() {
+		if stats.TotalTurns > 0 && turnChars > stats.LongestTurnChars {
+			stats.LongestTurn, stats.LongestTurnChars = stats.TotalTurns, turnChars
+		}
+	}
+
+	for _, msg := range messages {
+		text := messageText(msg)
+		switch messageRole(msg) {
+		case "user":
+			endTurn()
+			stats.TotalTurns++
+			turnChars = 0
+			stats.UserWords += len(strings.Fields(text))
+			if len(text) > stats.MostVerboseUserTurnChars {
+				stats.MostVerboseUserTurn, stats.MostVerboseUserTurnChars = stats.TotalTurns, len(text)
+			}
+		case "assistant":
+			stats.AssistantWords += len(strings.Fields(text))
+			if text != "" {
+				responses++
+				responseChars += len(text)
+			}
+			if m, ok := msg.(openai.ChatCompletionMessage); ok {
+				for _, call := range m.ToolCalls {
+					tools[call.Function.Name] = true
+				}
+			}
+		}
+		turnChars += len(text)
+	}
+	endTurn()
+
+	stats.UniqueTools = make([]string, 0, len(tools))
+	for name := range tools {
+		stats.UniqueTools = append(stats.UniqueTools, name)
+	}
+	sort.Strings(stats.UniqueTools)
+	if responses > 0 {
+		stats.AverageResponseLength = float64(responseChars) / float64(responses)
+	}
+	return stats
+}
+
+// runStats implements the stats subcommand
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	path := fs.String("session-file", "", "Session file to analyze")
+	jsonMode := fs.Bool("json-output", false, "Print the statistics as JSON")
+	fs.Parse(args)
+
+	if *path == "" {
+		return errors.New("stats: -session-file is required")
+	}
+	session, err := loadSession(*path)
+	if err != nil {
+		return err
+	}
+	stats := ConversationStatistics{}.Compute(session.Messages)
+	if *jsonMode {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	printStats(os.Stdout, stats)
+	return nil
+}
+
+// printStats writes a human readable statistics summary
+func printStats(w io.Writer, s Stats) {
+	tools := "none"
+	if len(s.UniqueTools) > 0 {
+		tools = strings.Join(s.UniqueTools, ", ")
+	}
+	fmt.Fprintf(w, "Total turns:             %d\n", s.TotalTurns)
+	fmt.Fprintf(w, "User words:              %d\n", s.UserWords)
+	fmt.Fprintf(w, "Assistant words:         %d\n", s.AssistantWords)
+	fmt.Fprintf(w, "Unique tools called:     %s\n", tools)
+	fmt.Fprintf(w, "Average response length: %.1f chars\n", s.AverageResponseLength)
+	fmt.Fprintf(w, "Longest turn:            #%d (%d chars)\n", s.LongestTurn, s.LongestTurnChars)
+	fmt.Fprintf(w, "Most verbose user turn:  #%d (%d chars)\n", s.MostVerboseUserTurn, s.MostVerboseUserTurnChars)
+}
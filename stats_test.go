@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestConversationStatistics(t *testing.T) {
+	assistant := func(content string, calls ...openai.ChatCompletionMessageToolCall) openai.ChatCompletionMessage {
+		return openai.ChatCompletionMessage{Role: openai.ChatCompletionMessageRoleAssistant, Content: content, ToolCalls: calls}
+	}
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.UserMessage("What is the weather in New York?"),
+		assistant("", testToolCall("call_1", "get_weather", `{"location":"New York"}`)),
+		openai.ToolMessage("call_1", "Sunny, 25C"),
+		assistant("It is sunny in New York."),
+		openai.UserMessage("And tomorrow?"),
+		assistant("", testToolCall("call_2", "get_forecast", `{"location":"New York"}`)),
+		openai.ToolMessage("call_2", "Rain"),
+		assistant("Rain is expected tomorrow."),
+	}
+
+	want := Stats{
+		TotalTurns:            2,
+		UserWords:             7 + 2,
+		AssistantWords:        6 + 4,
+		UniqueTools:           []string{"get_forecast", "get_weather"},
+		AverageResponseLength: (24 + 26) / 2.0,
+		// Turn 1 is 32 + 10 + 24 chars, turn 2 is 13 + 4 + 26
+		LongestTurn:              1,
+		LongestTurnChars:         66,
+		MostVerboseUserTurn:      1,
+		MostVerboseUserTurnChars: 32,
+	}
+	got := ConversationStatistics{}.Compute(messages)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compute =\n%+v\nwant\n%+v", got, want)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Stats
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, want) {
+		t.Errorf("JSON round trip = %+v, %v", decoded, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConversationStore lists, loads and deletes saved sessions by ID
+type ConversationStore interface {
+	List() ([]string, error)
+	Load(id string) (*Session, error)
+	Delete(id string) error
+}
+
+// FileConversationStore keeps one <id>.json (or gzipped <id>.json.gz) session file per conversation in Dir
+type FileConversationStore struct {
+	Dir string
+}
+
+const (
+	sessionFileExt           = ".json"
+	compressedSessionFileExt = ".json.gz"
+)
+
+// List returns the IDs of all sessions in the directory, sorted
+func (s FileConversationStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch name := entry.Name(); {
+		case strings.HasSuffix(name, sessionFileExt):
+			ids = append(ids, strings.TrimSuffix(name, sessionFileExt))
+		case strings.HasSuffix(name, compressedSessionFileExt):
+			ids = append(ids, strings.TrimSuffix(name, compressedSessionFileExt))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Load reads the session with the given ID
+func (s FileConversationStore) Load(id string) (*Session, error) {
+	return loadSession(s.path(id))
+}
+
+// Delete removes the session with the given ID, holding its -conversation-lock while doing so
+func (s FileConversationStore) Delete(id string) error {
+	path := s.path(id)
+	unlock, err := lockSession(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return os.Remove(path)
+}
+
+// path returns the session file for id, preferring the compressed file when it exists
+func (s FileConversationStore) path(id string) string {
+	compressed := filepath.Join(s.Dir, id+compressedSessionFileExt)
+	if _, err := os.Stat(compressed); err == nil {
+		return compressed
+	}
+	return filepath.Join(s.Dir, id+sessionFileExt)
+}
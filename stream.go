@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// streamRequest streams a completion, echoing content to stdout or showing
+// a progress line when -streaming-progress is set
+func streamRequest(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	printed := false
+	onDelta := func(delta string) {
+		printed = true
+		fmt.Print(delta)
+	}
+	if *streamingProgress {
+		monitor := &StreamingProgressMonitor{}
+		monitor.Start(os.Stderr)
+		defer monitor.Stop()
+		onDelta = monitor.ObserveChunk
+	}
+
+	var resp *openai.ChatCompletion
+	var err error
+	if *gatewayWebsocket {
+		var ws *WebSocketGatewayClient
+		if ws, err = newWebSocketGatewayClient(*aiGatewayURL); err == nil {
+			resp, err = ws.Stream(ctx, params, onDelta)
+		}
+	} else {
+		resp, err = sendStreamingRequest(ctx, client, params, onDelta)
+	}
+	if printed {
+		fmt.Println()
+	}
+	if err == nil {
+		trackCost(resp)
+	}
+	return resp, err
+}
+
+// sendStreamingRequest streams the request, calling onDelta with each content delta,
+// and returns the accumulated completion
+func sendStreamingRequest(ctx context.Context, client *openai.Client, params openai.ChatCompletionNewParams, onDelta func(string)) (*openai.ChatCompletion, error) {
+	ctx, cancel := withGatewayTimeout(ctx, gatewayCallTimeoutMs())
+	defer cancel()
+
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var acc openai.ChatCompletionAccumulator
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if onDelta != nil && len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	if len(acc.Choices) == 0 {
+		return nil, errors.New("stream ended without any choices")
+	}
+	acc.Choices[0].Message.Role = openai.ChatCompletionMessageRoleAssistant
+	return &acc.ChatCompletion, nil
+}
+
+// progressInterval is how often StreamingProgressMonitor refreshes its line
+const progressInterval = 500 * time.Millisecond
+
+// progressSmoothing is the EMA weight for a 5-interval window (2 / (N + 1))
+const progressSmoothing = 2.0 / (5 + 1)
+
+// StreamingProgressMonitor approximates the token rate of a stream by word count
+// and shows it on a single terminal line
+type StreamingProgressMonitor struct {
+	tokens atomic.Int64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	w    io.Writer
+}
+
+// ObserveChunk counts the words in a content delta
+func (m *StreamingProgressMonitor) ObserveChunk(delta string) {
+	m.tokens.Add(int64(len(strings.Fields(delta))))
+}
+
+// Tokens returns the number of tokens observed so far
+func (m *StreamingProgressMonitor) Tokens() int64 {
+	return m.tokens.Load()
+}
+
+// Start refreshes the progress line on w every progressInterval until Stop is called
+func (m *StreamingProgressMonitor) Start(w io.Writer) {
+	m.w = w
+	m.stop = make(chan struct{})
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		var last int64
+		var rate float64
+		first := true
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				current := m.Tokens()
+				sample := float64(current-last) / progressInterval.Seconds()
+				last = current
+				if first {
+					rate, first = sample, false
+				} else {
+					rate = progressSmoothing*sample + (1-progressSmoothing)*rate
+				}
+				fmt.Fprintf(w, "\rStreaming... %.0f tokens/s ", rate)
+			}
+		}
+	}()
+}
+
+// Stop ends the refresh loop and clears the progress line
+func (m *StreamingProgressMonitor) Stop() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	m.wg.Wait()
+	m.stop = nil
+	fmt.Fprint(m.w, "\r\033[K")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamingProgressMonitorObserveChunk(t *testing.T) {
+	var m StreamingProgressMonitor
+	for _, delta := range []string{"The weather", " in New York", "", " is sunny.\n", "  "} {
+		m.ObserveChunk(delta)
+	}
+	if got := m.Tokens(); got != 7 {
+		t.Errorf("Tokens() = %d, want 7", got)
+	}
+}
+
+func TestStreamingProgressMonitorStartStop(t *testing.T) {
+	var out bytes.Buffer
+	var m StreamingProgressMonitor
+	m.Start(&out)
+	for i := 0; i < 10; i++ {
+		m.ObserveChunk("one two three four five")
+	}
+	time.Sleep(progressInterval + 100*time.Millisecond)
+	m.Stop()
+	m.Stop()
+
+	got := out.String()
+	if !strings.Contains(got, "\rStreaming... 100 tokens/s ") {
+		t.Errorf("progress line missing the 50 tokens over 0.5s rate: %q", got)
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("Stop did not clear the progress line: %q", got)
+	}
+}
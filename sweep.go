@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParameterSweep runs the same conversation once per temperature value
+type ParameterSweep struct {
+	Temperatures []float64
+	Concurrency  int
+}
+
+// SweepResult holds the outcome of a single sweep run
+type SweepResult struct {
+	Temperature float64
+	Response    string
+	Err         error
+}
+
+// Run calls runFn for every temperature, at most Concurrency at a time,
+// and returns the results in the same order as Temperatures
+func (s ParameterSweep) Run(ctx context.Context, runFn func(temp float64) (string, error)) []SweepResult {
+	results := make([]SweepResult, len(s.Temperatures))
+	limit := s.Concurrency
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, temp := range s.Temperatures {
+		results[i].Temperature = temp
+		select {
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int, temp float64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Response, results[i].Err = runFn(temp)
+		}(i, temp)
+	}
+	wg.Wait()
+	return results
+}
+
+// parseTemperatures parses a comma-separated list of temperatures
+func parseTemperatures(value string) ([]float64, error) {
+	var temperatures []float64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		temp, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid temperature %q: %w", part, err)
+		}
+		temperatures = append(temperatures, temp)
+	}
+	if len(temperatures) == 0 {
+		return nil, fmt.Errorf("no temperatures given")
+	}
+	return temperatures, nil
+}
+
+// printSweepResults prints each response labeled with its temperature followed by a summary
+func printSweepResults(w io.Writer, results []SweepResult) {
+	for _, r := range results {
+		fmt.Fprintf(w, "=== temperature=%.2f ===\n", r.Temperature)
+		if r.Err != nil {
+			fmt.Fprintf(w, "error: %v\n\n", r.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\n\n", r.Response)
+	}
+
+	fmt.Fprintln(w, "Summary:")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(w, "  temperature=%.2f  error\n", r.Temperature)
+			continue
+		}
+		fmt.Fprintf(w, "  temperature=%.2f  length=%d  first sentence: %s\n", r.Temperature, len(r.Response), firstSentence(r.Response))
+	}
+}
+
+// firstSentence returns the text up to and including the first sentence terminator
+// that is followed by whitespace or the end of the text
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '.', '!', '?':
+			if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n' || text[i+1] == '\t' {
+				return text[:i+1]
+			}
+		}
+	}
+	return text
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// trackPeak raises *peak to n if n is larger
+func trackPeak(peak *int32, n int32) {
+	for {
+		p := atomic.LoadInt32(peak)
+		if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+			return
+		}
+	}
+}
+
+func TestParameterSweepRun(t *testing.T) {
+	temps := []float64{0.0, 0.5, 1.0, 1.5}
+	var inflight, peak int32
+	sweep := ParameterSweep{Temperatures: temps, Concurrency: 2}
+	results := sweep.Run(context.Background(), func(temp float64) (string, error) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		trackPeak(&peak, n)
+		return fmt.Sprintf("Temperature %.1f. Extra text.", temp), nil
+	})
+
+	if peak > 2 {
+		t.Errorf("%d runs in flight, want at most 2", peak)
+	}
+	var out bytes.Buffer
+	printSweepResults(&out, results)
+	for i, temp := range temps {
+		if results[i].Temperature != temp {
+			t.Errorf("result %d has temperature %v, want %v", i, results[i].Temperature, temp)
+		}
+		label := fmt.Sprintf("=== temperature=%.2f ===", temp)
+		echoed := fmt.Sprintf("Temperature %.1f.", temp)
+		if !strings.Contains(out.String(), label) || !strings.Contains(out.String(), echoed) {
+			t.Errorf("output is missing temperature %v:\n%s", temp, out.String())
+		}
+	}
+	summary := fmt.Sprintf("temperature=0.50  length=%d  first sentence: Temperature 0.5.\n", len("Temperature 0.5. Extra text."))
+	if !strings.Contains(out.String(), summary) {
+		t.Errorf("summary line %q missing:\n%s", summary, out.String())
+	}
+}
+
+func TestPrintSweepResultsReportsErrors(t *testing.T) {
+	var out bytes.Buffer
+	printSweepResults(&out, []SweepResult{{Temperature: 2, Err: errors.New("boom")}})
+	if !strings.Contains(out.String(), "error: boom") || !strings.Contains(out.String(), "temperature=2.00  error") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestParseTemperatures(t *testing.T) {
+	got, err := parseTemperatures("0.0, 0.5,1.0,")
+	if err != nil || len(got) != 3 || got[1] != 0.5 {
+		t.Errorf("parseTemperatures = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "hot", ","} {
+		if _, err := parseTemperatures(bad); err == nil {
+			t.Errorf("parseTemperatures(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestFirstSentence(t *testing.T) {
+	tests := map[string]string{
+		"It is sunny. Bring sunscreen.": "It is sunny.",
+		"Version 2.5 is out! Try it.":   "Version 2.5 is out!",
+		"No terminator":                 "No terminator",
+	}
+	for in, want := range tests {
+		if got := firstSentence(in); got != want {
+			t.Errorf("firstSentence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
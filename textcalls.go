@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+var (
+	// textCallPattern matches Python-style calls such as get_weather(location="New York City")
+	textCallPattern = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\(([^()]*)\)`)
+	// textCallArgPattern matches one keyword argument with a quoted or bare value
+	textCallArgPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*=\s*("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|[^,\s)]+)`)
+)
+
+// parseFunctionCallsFromText finds calls to registered tools written as Python function
+// calls in text and turns them into tool calls. Only keyword arguments are understood.
+func parseFunctionCallsFromText(text string, registry *ToolRegistry) []openai.ChatCompletionMessageToolCall {
+	var calls []openai.ChatCompletionMessageToolCall
+	for _, m := range textCallPattern.FindAllStringSubmatch(text, -1) {
+		name, rawArgs := m[1], m[2]
+		if _, ok := registry.Get(name); !ok {
+			continue
+		}
+		args := make(map[string]interface{})
+		for _, a := range textCallArgPattern.FindAllStringSubmatch(rawArgs, -1) {
+			args[a[1]] = parseTextCallValue(a[2])
+		}
+		data, err := json.Marshal(args)
+		if err != nil {
+			continue
+		}
+		calls = append(calls, openai.ChatCompletionMessageToolCall{
+			ID:   fmt.Sprintf("call_text_%d", len(calls)+1),
+			Type: openai.ChatCompletionMessageToolCallTypeFunction,
+			Function: openai.ChatCompletionMessageToolCallFunction{
+				Name:      name,
+				Arguments: string(data),
+			},
+		})
+	}
+	return calls
+}
+
+// parseTextCallValue converts a Python literal to its JSON counterpart, keeping
+// anything it does not recognize, such as an unterminated string, as a string
+func parseTextCallValue(v string) interface{} {
+	switch {
+	case isQuoted(v, `"`):
+		if s, err := strconv.Unquote(v); err == nil {
+			return s
+		}
+		return v[1 : len(v)-1]
+	case isQuoted(v, "'"):
+		inner := strings.ReplaceAll(v[1:len(v)-1], `\'`, `'`)
+		if s, err := strconv.Unquote(`"` + strings.ReplaceAll(inner, `"`, `\"`) + `"`); err == nil {
+			return s
+		}
+		return inner
+	case v == "True" || v == "true":
+		return true
+	case v == "False" || v == "false":
+		return false
+	case v == "None" || v == "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		return n
+	}
+	return v
+}
+
+// isQuoted reports whether v is enclosed in a pair of quote characters
+func isQuoted(v, quote string) bool {
+	return len(v) >= 2 && strings.HasPrefix(v, quote) && strings.HasSuffix(v, quote)
+}
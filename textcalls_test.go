@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestParseFunctionCallsFromText(t *testing.T) {
+	registry, err := newDefaultToolRegistry(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := `I will check both cities: get_weather(location="New York City") and get_weather(location='Paris, France', days=3, alerts=True). unknown_tool(x=1)`
+	got := parseFunctionCallsFromText(text, registry)
+	want := []openai.ChatCompletionMessageToolCall{
+		testToolCall("call_text_1", "get_weather", `{"location":"New York City"}`),
+		testToolCall("call_text_2", "get_weather", `{"alerts":true,"days":3,"location":"Paris, France"}`),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFunctionCallsFromText =\n%+v\nwant\n%+v", got, want)
+	}
+	if calls := parseFunctionCallsFromText("It is sunny in New York.", registry); len(calls) != 0 {
+		t.Errorf("plain text produced %d calls", len(calls))
+	}
+}
+
+func TestParseFunctionCallsFromTextMalformed(t *testing.T) {
+	registry, err := newDefaultToolRegistry(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`get_weather(location=')`, `{"location":"'"}`},
+		{`get_weather(location=")`, `{"location":"\""}`},
+		{`get_weather(location='NYC)`, `{"location":"'NYC"}`},
+		{`get_weather(location="NYC)`, `{"location":"\"NYC"}`},
+		{`get_weather(location='N\'Y')`, `{"location":"N'Y"}`},
+	}
+	for _, tt := range tests {
+		calls := parseFunctionCallsFromText(tt.text, registry)
+		if len(calls) != 1 {
+			t.Errorf("%s: got %d calls, want 1", tt.text, len(calls))
+			continue
+		}
+		if calls[0].Function.Arguments != tt.want {
+			t.Errorf("%s: arguments %s, want %s", tt.text, calls[0].Function.Arguments, tt.want)
+		}
+	}
+}
+
+func TestParseTextCallValue(t *testing.T) {
+	tests := []struct {
+		v    string
+		want interface{}
+	}{
+		{`"a\"b"`, `a"b`},
+		{`'it''`, `it'`},
+		{`''`, ""},
+		{`'`, `'`},
+		{`42`, 42.0},
+		{`False`, false},
+		{`None`, nil},
+		{`NYC`, "NYC"},
+	}
+	for _, tt := range tests {
+		if got := parseTextCallValue(tt.v); got != tt.want {
+			t.Errorf("parseTextCallValue(%s) = %#v, want %#v", tt.v, got, tt.want)
+		}
+	}
+}
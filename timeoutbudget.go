@@ -0,0 +1,44 @@
+package main
+
+import (
+	"time"
+)
+
+// budgetBufferFraction is the share of the remaining budget a single call may use
+const budgetBufferFraction = 0.8
+
+// BudgetAwareTimeout shrinks per-call timeouts as the overall -timeout budget is used up
+type BudgetAwareTimeout struct {
+	Total    time.Duration
+	Consumed time.Duration
+}
+
+// NextCallTimeout returns base capped to 80% of the remaining budget, keeping a 20% buffer
+// for tool dispatch and retries. base <= 0 means no per-call limit of its own. Once the
+// budget is spent the result is 1ms so the call fails fast.
+func (b BudgetAwareTimeout) NextCallTimeout(base time.Duration) time.Duration {
+	if b.Total <= 0 {
+		return base
+	}
+	budget := time.Duration(float64(b.Total-b.Consumed) * budgetBufferFraction)
+	if budget < time.Millisecond {
+		budget = time.Millisecond
+	}
+	if base > 0 && base < budget {
+		return base
+	}
+	return budget
+}
+
+// timeoutBudgetStart is when the -timeout budget started counting, set in main
+var timeoutBudgetStart time.Time
+
+// gatewayCallTimeoutMs returns the timeout for the next gateway call: -gateway-timeout-ms,
+// reduced by -gateway-timeout-budget-aware as the -timeout deadline approaches
+func gatewayCallTimeoutMs() int {
+	if !*gatewayTimeoutBudgetAware {
+		return *gatewayTimeoutMs
+	}
+	budget := BudgetAwareTimeout{Total: *requestTimeout, Consumed: time.Since(timeoutBudgetStart)}
+	return int(budget.NextCallTimeout(time.Duration(*gatewayTimeoutMs) * time.Millisecond).Milliseconds())
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetAwareTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		total, consumed time.Duration
+		base            time.Duration
+		want            time.Duration
+	}{
+		{"40s of 60s used", 60 * time.Second, 40 * time.Second, 30 * time.Second, 16 * time.Second},
+		{"no base", 60 * time.Second, 40 * time.Second, 0, 16 * time.Second},
+		{"base below budget", 60 * time.Second, 40 * time.Second, 5 * time.Second, 5 * time.Second},
+		{"fresh budget", 60 * time.Second, 0, 0, 48 * time.Second},
+		{"budget spent", 60 * time.Second, 61 * time.Second, 30 * time.Second, time.Millisecond},
+		{"no budget", 0, 40 * time.Second, 30 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		b := BudgetAwareTimeout{Total: tt.total, Consumed: tt.consumed}
+		if got := b.NextCallTimeout(tt.base); got != tt.want {
+			t.Errorf("%s: NextCallTimeout(%v) = %v, want %v", tt.name, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestGatewayCallTimeoutMs(t *testing.T) {
+	setFlag(t, gatewayTimeoutMs, 30000)
+	setFlag(t, requestTimeout, 60*time.Second)
+	setFlag(t, &timeoutBudgetStart, time.Now().Add(-40*time.Second))
+
+	setFlag(t, gatewayTimeoutBudgetAware, false)
+	if got := gatewayCallTimeoutMs(); got != 30000 {
+		t.Errorf("without -gateway-timeout-budget-aware: %dms, want 30000ms", got)
+	}
+	setFlag(t, gatewayTimeoutBudgetAware, true)
+	if got := gatewayCallTimeoutMs(); got > 16000 || got < 15000 {
+		t.Errorf("after 40s of a 60s budget: %dms, want at most 16000ms", got)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// autoTitleMaxLen is the longest title -auto-title-from-response generates
+const autoTitleMaxLen = 60
+
+// extractAutoTitle turns the first sentence of response, cut at maxLen characters,
+// into a slug such as "the-weather-in-new-york-is-sunny"
+func extractAutoTitle(response string, maxLen int) string {
+	sentence := strings.TrimSpace(strings.TrimRight(firstSentence(response), ".!?"))
+	if runes := []rune(sentence); len(runes) > maxLen {
+		sentence = string(runes[:maxLen])
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(sentence) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// applySessionTitle sets the session title from -conversation-title, or from the
+// first response when -auto-title-from-response is set and there is no title yet
+func applySessionTitle(session *Session, responseText string) {
+	switch {
+	case *conversationTitle != "":
+		session.Metadata.Title = *conversationTitle
+	case *autoTitleFromResponse && session.Metadata.Title == "":
+		session.Metadata.Title = extractAutoTitle(responseText, autoTitleMaxLen)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestExtractAutoTitle(t *testing.T) {
+	tests := []struct {
+		response string
+		maxLen   int
+		want     string
+	}{
+		{"The weather in New York is sunny. It is 25°C.", autoTitleMaxLen, "the-weather-in-new-york-is-sunny"},
+		{"Is it raining in Paris? Yes.", autoTitleMaxLen, "is-it-raining-in-paris"},
+		{"  Sunny -- 25°C, light wind!  ", autoTitleMaxLen, "sunny-25c-light-wind"},
+		{"The weather in New York is sunny.", 15, "the-weather-in"},
+		{"", autoTitleMaxLen, ""},
+	}
+	for _, tt := range tests {
+		if got := extractAutoTitle(tt.response, tt.maxLen); got != tt.want {
+			t.Errorf("extractAutoTitle(%q, %d) = %q, want %q", tt.response, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
+func TestApplySessionTitle(t *testing.T) {
+	setFlag(t, autoTitleFromResponse, true)
+	session := newSession()
+	applySessionTitle(session, "The weather in New York is sunny.")
+	applySessionTitle(session, "Tomorrow it will rain.")
+	if session.Metadata.Title != "the-weather-in-new-york-is-sunny" {
+		t.Errorf("title = %q, want the first response's title", session.Metadata.Title)
+	}
+
+	setFlag(t, conversationTitle, "demo")
+	applySessionTitle(session, "Tomorrow it will rain.")
+	if session.Metadata.Title != "demo" {
+		t.Errorf("title = %q, want -conversation-title", session.Metadata.Title)
+	}
+}
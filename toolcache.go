@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	openai "github.com/openai/openai-go"
+)
+
+// toolCallRecord is a tool result remembered by SessionToolCache
+type toolCallRecord struct {
+	Name     string
+	ArgsHash string
+	Result   string
+}
+
+// SessionToolCache remembers the results of the last Window unique tool calls
+// across all turns of a session
+type SessionToolCache struct {
+	Window int
+
+	mu      sync.Mutex
+	history []toolCallRecord
+}
+
+// Lookup returns the cached result for an identical call within the window
+func (c *SessionToolCache) Lookup(name, argsHash string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookup(name, argsHash)
+}
+
+func (c *SessionToolCache) lookup(name, argsHash string) (string, bool) {
+	for _, r := range c.history {
+		if r.Name == name && r.ArgsHash == argsHash {
+			return r.Result, true
+		}
+	}
+	return "", false
+}
+
+// Record remembers a result, keeping the first result for calls already in the window
+func (c *SessionToolCache) Record(name, argsHash, result string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.lookup(name, argsHash); ok {
+		return
+	}
+	c.history = append(c.history, toolCallRecord{Name: name, ArgsHash: argsHash, Result: result})
+	if c.Window > 0 && len(c.history) > c.Window {
+		c.history = c.history[len(c.history)-c.Window:]
+	}
+}
+
+// Seed records the tool calls and results of earlier turns in the session
+func (c *SessionToolCache) Seed(messages []openai.ChatCompletionMessageParamUnion) {
+	calls := map[string]openai.ChatCompletionMessageToolCall{}
+	for _, msg := range messages {
+		if assistant, ok := msg.(openai.ChatCompletionMessage); ok {
+			for _, call := range assistant.ToolCalls {
+				calls[call.ID] = call
+			}
+			continue
+		}
+		call, ok := calls[toolCallID(msg)]
+		if !ok {
+			continue
+		}
+		if result, ok := unannotatedToolResult(messageText(msg)); ok {
+			c.Record(call.Function.Name, hashToolArguments(call.Function.Arguments), result)
+		}
+	}
+}
+
+// languageNotePattern matches the note languageNote prepends to a tool result
+var languageNotePattern = regexp.MustCompile(`^\[Note: tool result is in [^\]]+\. Translate before using\.\] `)
+
+// unannotatedToolResult strips the annotations handleToolCall adds to a saved tool message,
+// returning false for error and redacted results, whose raw result is not known
+func unannotatedToolResult(text string) (string, bool) {
+	text = languageNotePattern.ReplaceAllString(text, "")
+	if text == injectionRedactedResult || strings.HasPrefix(text, "Error: ") {
+		return "", false
+	}
+	return strings.TrimPrefix(text, unverifiedResultPrefix), true
+}
+
+// hashToolArguments hashes the canonical JSON form of the arguments so key order does not matter
+func hashToolArguments(arguments string) string {
+	canonical := []byte(arguments)
+	var v interface{}
+	if err := json.Unmarshal(canonical, &v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			canonical = b
+		}
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestSessionToolCacheAcrossTurns(t *testing.T) {
+	calls := 0
+	registry := stubToolRegistry(t, "get_weather", func(_ context.Context, args map[string]interface{}) (string, error) {
+		calls++
+		return "Sunny in " + args["location"].(string), nil
+	})
+	registry.Cache = &SessionToolCache{Window: 5}
+	ctx := context.Background()
+
+	turns := []string{`{"location":"Paris"}`, `{"location":"Berlin"}`, `{ "location": "Paris" }`}
+	var results []string
+	for i, args := range turns {
+		msg := handleToolCall(ctx, registry, testToolCall(fmt.Sprintf("call_%d", i+1), "get_weather", args))
+		results = append(results, messageText(msg))
+	}
+	if calls != 2 {
+		t.Errorf("tool ran %d times, want 2 (turn 3 should hit the cache)", calls)
+	}
+	if results[2] != results[0] {
+		t.Errorf("turn 3 result %q, want the cached turn 1 result %q", results[2], results[0])
+	}
+}
+
+func TestSessionToolCacheWindow(t *testing.T) {
+	cache := &SessionToolCache{Window: 2}
+	cache.Record("t", "a", "A")
+	cache.Record("t", "b", "B")
+	cache.Record("t", "c", "C")
+	if _, ok := cache.Lookup("t", "a"); ok {
+		t.Error("oldest entry was not evicted")
+	}
+	if got, ok := cache.Lookup("t", "c"); !ok || got != "C" {
+		t.Errorf("Lookup(c) = %q, %v", got, ok)
+	}
+	cache.Record("t", "c", "changed")
+	if got, _ := cache.Lookup("t", "c"); got != "C" {
+		t.Errorf("Record replaced the first result with %q", got)
+	}
+}
+
+func TestSessionToolCacheSeedStripsAnnotations(t *testing.T) {
+	setFlag(t, toolResultLanguageDetect, true)
+	setFlag(t, expectedLanguage, "en")
+	const raw = "Le temps est ensoleillé et il fait très chaud aujourd'hui dans la ville de Paris."
+	note := languageNote(raw, "en")
+	if note == "" {
+		t.Fatal("test result was not detected as non-English")
+	}
+
+	call := testToolCall("call_1", "get_weather", `{"location":"Paris"}`)
+	history := []openai.ChatCompletionMessageParamUnion{
+		openai.UserMessage("Weather in Paris?"),
+		openai.ChatCompletionMessage{Role: openai.ChatCompletionMessageRoleAssistant, ToolCalls: []openai.ChatCompletionMessageToolCall{call}},
+		openai.ToolMessage("call_1", note+" "+unverifiedResultPrefix+raw),
+		openai.ChatCompletionMessage{Role: openai.ChatCompletionMessageRoleAssistant, ToolCalls: []openai.ChatCompletionMessageToolCall{testToolCall("call_2", "get_weather", `{"location":"Oslo"}`)}},
+		openai.ToolMessage("call_2", injectionRedactedResult),
+	}
+
+	ran := 0
+	registry := stubToolRegistry(t, "get_weather", func(context.Context, map[string]interface{}) (string, error) {
+		ran++
+		return "Sunny", nil
+	})
+	registry.Cache = &SessionToolCache{Window: 5}
+	registry.Cache.Seed(history)
+
+	if got, ok := registry.Cache.Lookup("get_weather", hashToolArguments(call.Function.Arguments)); !ok || got != raw {
+		t.Errorf("seeded result = %q, %v; want the raw result", got, ok)
+	}
+	if _, ok := registry.Cache.Lookup("get_weather", hashToolArguments(`{"location":"Oslo"}`)); ok {
+		t.Error("a redacted result was seeded")
+	}
+
+	resumed := messageText(handleToolCall(context.Background(), registry, testToolCall("call_3", "get_weather", `{"location":"Paris"}`)))
+	if ran != 0 {
+		t.Error("resumed call was not answered from the seeded cache")
+	}
+	if n := strings.Count(resumed, "[Note:"); n != 1 {
+		t.Errorf("resumed result has %d language notes, want 1: %q", n, resumed)
+	}
+	if strings.Contains(resumed, unverifiedResultPrefix) {
+		t.Errorf("resumed result kept the unverified prefix without verification: %q", resumed)
+	}
+}
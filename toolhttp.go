@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// toolAuthHeaders maps tool names to the Authorization header value for their HTTP calls
+var toolAuthHeaders map[string]string
+
+// parseToolAuthHeaders parses -tool-auth-headers JSON and validates each value
+func parseToolAuthHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	if value == "" {
+		return headers, nil
+	}
+	if err := json.Unmarshal([]byte(value), &headers); err != nil {
+		return nil, fmt.Errorf("parsing tool auth headers: %w", err)
+	}
+	for tool, header := range headers {
+		if strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("auth header for tool %q is empty", tool)
+		}
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, fmt.Errorf("auth header for tool %q contains a newline", tool)
+		}
+	}
+	return headers, nil
+}
+
+// AuthenticatedToolTransport adds the Authorization header configured for ToolName
+type AuthenticatedToolTransport struct {
+	Base     http.RoundTripper
+	ToolName string
+	Headers  map[string]string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *AuthenticatedToolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header, ok := t.Headers[t.ToolName]
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", header)
+	return base.RoundTrip(req)
+}
+
+// toolHTTPClient returns the HTTP client used for calls made by the named tool
+func toolHTTPClient(toolName string) *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &AuthenticatedToolTransport{ToolName: toolName, Headers: toolAuthHeaders},
+	}
+}
+
+// toolHTTPMethods maps tool names to the HTTP method of their service; GET is the default
+var toolHTTPMethods map[string]string
+
+// parseToolHTTPMethods parses the -tool-http-methods JSON object, accepting GET and POST
+func parseToolHTTPMethods(value string) (map[string]string, error) {
+	methods := map[string]string{}
+	if value == "" {
+		return methods, nil
+	}
+	if err := json.Unmarshal([]byte(value), &methods); err != nil {
+		return nil, fmt.Errorf("parsing tool HTTP methods: %w", err)
+	}
+	for tool, method := range methods {
+		method = strings.ToUpper(method)
+		if method != http.MethodGet && method != http.MethodPost {
+			return nil, fmt.Errorf("unsupported method %q for tool %q", method, tool)
+		}
+		methods[tool] = method
+	}
+	return methods, nil
+}
+
+// buildToolRequest builds a tool service request, sending args as query parameters for
+// GET and as a JSON body for POST
+func buildToolRequest(ctx context.Context, method, serviceURL string, args map[string]interface{}) (*http.Request, error) {
+	switch method {
+	case http.MethodGet, "":
+		u, err := url.Parse(serviceURL)
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		for k, v := range args {
+			q.Set(k, fmt.Sprint(v))
+		}
+		u.RawQuery = q.Encode()
+		return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	case http.MethodPost:
+		body, err := json.Marshal(args)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, serviceURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	default:
+		return nil, fmt.Errorf("unsupported tool HTTP method %q", method)
+	}
+}
+
+// fetchTool calls the service for toolName at serviceURL with the method from
+// -tool-http-methods and returns the response body
+func fetchTool(ctx context.Context, toolName, serviceURL string, args map[string]interface{}) (string, error) {
+	req, err := buildToolRequest(ctx, toolHTTPMethods[toolName], serviceURL, args)
+	if err != nil {
+		return "", err
+	}
+	resp, err := toolHTTPClient(toolName).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("%s service returned %s", toolName, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// fetchWeather calls the external weather service for location
+func fetchWeather(ctx context.Context, serviceURL, location string) (string, error) {
+	return fetchTool(ctx, "get_weather", serviceURL, map[string]interface{}{"location": location})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestToolAuthHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("Sunny, 25°C\n"))
+	}))
+	defer srv.Close()
+
+	headers, err := parseToolAuthHeaders(`{"get_weather": "Bearer test-token"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, &toolAuthHeaders, headers)
+	got, err := fetchWeather(context.Background(), srv.URL, "New York City")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Sunny, 25°C" {
+		t.Errorf("got %q", got)
+	}
+
+	setFlag(t, &toolAuthHeaders, map[string]string{"other_tool": "Bearer test-token"})
+	if _, err := fetchWeather(context.Background(), srv.URL, "New York City"); err == nil {
+		t.Error("the header for another tool was sent to get_weather")
+	}
+}
+
+func TestParseToolAuthHeaders(t *testing.T) {
+	for _, bad := range []string{`{"t": ""}`, `{"t": "  "}`, `{"t": "Bearer a\nX-Evil: 1"}`, `not json`} {
+		if _, err := parseToolAuthHeaders(bad); err == nil {
+			t.Errorf("parseToolAuthHeaders(%q) succeeded", bad)
+		}
+	}
+	if headers, err := parseToolAuthHeaders(""); err != nil || len(headers) != 0 {
+		t.Errorf("empty flag = %v, %v", headers, err)
+	}
+}
+
+func TestToolHTTPMethods(t *testing.T) {
+	type received struct {
+		method, contentType, query string
+		body                       []byte
+	}
+	requests := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Method, r.Header.Get("Content-Type"), r.URL.RawQuery, body}
+		w.Write([]byte("Sunny"))
+	}))
+	defer srv.Close()
+
+	setFlag(t, &toolHTTPMethods, map[string]string{"get_weather": http.MethodPost})
+	if _, err := fetchWeather(context.Background(), srv.URL, "New York City"); err != nil {
+		t.Fatal(err)
+	}
+	post := <-requests
+	var args map[string]interface{}
+	if err := json.Unmarshal(post.body, &args); err != nil {
+		t.Fatalf("POST body %q: %v", post.body, err)
+	}
+	if post.method != http.MethodPost || post.contentType != "application/json" || post.query != "" || args["location"] != "New York City" {
+		t.Errorf("POST request = %s %q query %q body %s", post.method, post.contentType, post.query, post.body)
+	}
+
+	setFlag(t, &toolHTTPMethods, map[string]string{})
+	if _, err := fetchWeather(context.Background(), srv.URL, "New York City"); err != nil {
+		t.Fatal(err)
+	}
+	get := <-requests
+	if get.method != http.MethodGet || get.query != "location=New+York+City" || len(get.body) != 0 {
+		t.Errorf("GET request = %s query %q body %q", get.method, get.query, get.body)
+	}
+}
+
+func TestParseToolHTTPMethods(t *testing.T) {
+	methods, err := parseToolHTTPMethods(`{"get_weather": "post"}`)
+	if err != nil || methods["get_weather"] != http.MethodPost {
+		t.Errorf("parseToolHTTPMethods = %v, %v", methods, err)
+	}
+	if _, err := parseToolHTTPMethods(`{"get_weather": "DELETE"}`); err == nil {
+		t.Error("DELETE accepted")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PerTypeParallelLimiter caps how many calls to each tool run at once. Tools without a
+// limit are not restricted.
+type PerTypeParallelLimiter struct {
+	slots map[string]chan struct{}
+}
+
+// NewPerTypeParallelLimiter returns a limiter allowing limits[name] concurrent calls per tool
+func NewPerTypeParallelLimiter(limits map[string]int) *PerTypeParallelLimiter {
+	slots := make(map[string]chan struct{}, len(limits))
+	for name, limit := range limits {
+		slots[name] = make(chan struct{}, limit)
+	}
+	return &PerTypeParallelLimiter{slots: slots}
+}
+
+// Acquire waits for a slot for tool name and returns the function that releases it
+func (l *PerTypeParallelLimiter) Acquire(ctx context.Context, name string) (release func(), err error) {
+	slot, ok := l.slots[name]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// parseToolParallelLimits decodes the -tool-parallel-limit-per-type JSON object
+func parseToolParallelLimits(value string) (map[string]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var limits map[string]int
+	if err := json.Unmarshal([]byte(value), &limits); err != nil {
+		return nil, fmt.Errorf("parsing tool parallel limits: %w", err)
+	}
+	for name, limit := range limits {
+		if limit < 1 {
+			return nil, fmt.Errorf("limit for tool %q must be at least 1, got %d", name, limit)
+		}
+	}
+	return limits, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+// peakTracker counts the calls of one tool in flight and the most seen at once
+type peakTracker struct {
+	inflight, peak int32
+}
+
+func (p *peakTracker) handler(context.Context, map[string]interface{}) (string, error) {
+	trackPeak(&p.peak, atomic.AddInt32(&p.inflight, 1))
+	defer atomic.AddInt32(&p.inflight, -1)
+	time.Sleep(20 * time.Millisecond)
+	return "ok", nil
+}
+
+func TestPerTypeParallelLimit(t *testing.T) {
+	var weather, forecast peakTracker
+	registry := NewToolRegistry(0)
+	for name, tracker := range map[string]*peakTracker{"get_weather": &weather, "get_forecast": &forecast} {
+		err := registry.Register(Tool{
+			Name:       name,
+			Parameters: openai.FunctionParameters{"type": "object", "properties": map[string]interface{}{}},
+			Handler:    tracker.handler,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry.ParallelLimit = NewPerTypeParallelLimiter(map[string]int{"get_weather": 2})
+
+	calls := testToolCallsNamed("get_weather", "get_weather", "get_weather", "get_weather", "get_weather",
+		"get_forecast", "get_forecast", "get_forecast")
+	messages, err := dispatchToolCallsConcurrently(context.Background(), calls, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, msg := range messages {
+		if got := messageText(msg); got != "ok" {
+			t.Errorf("call %d result = %q, want ok", i, got)
+		}
+	}
+	if weather.peak > 2 {
+		t.Errorf("%d get_weather calls in flight, want at most 2 with limit 2", weather.peak)
+	}
+	if forecast.peak < 1 || forecast.peak > 3 {
+		t.Errorf("%d get_forecast calls in flight, want between 1 and 3", forecast.peak)
+	}
+}
+
+func TestPerTypeParallelLimiterAcquireCancelled(t *testing.T) {
+	limiter := NewPerTypeParallelLimiter(map[string]int{"get_weather": 1})
+	release, err := limiter.Acquire(context.Background(), "get_weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, "get_weather"); err == nil {
+		t.Error("acquired a second slot with limit 1")
+	}
+}
+
+func TestParseToolParallelLimits(t *testing.T) {
+	limits, err := parseToolParallelLimits(`{"get_weather": 2, "search": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 2 || limits["get_weather"] != 2 || limits["search"] != 1 {
+		t.Errorf("limits = %v", limits)
+	}
+	for _, value := range []string{`{"get_weather": 0}`, `{"get_weather": "2"}`, `[2]`} {
+		if _, err := parseToolParallelLimits(value); err == nil {
+			t.Errorf("parseToolParallelLimits(%s) accepted", value)
+		}
+	}
+}
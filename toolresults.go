@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	openai "github.com/openai/openai-go"
+)
+
+// errStoppedAfterTools is returned by runConversation when -output-only-tool-results
+// ends the conversation before the final request
+var errStoppedAfterTools = errors.New("stopped after tool dispatch")
+
+// toolResultOutput is the -json-output form of a single tool result
+type toolResultOutput struct {
+	Tool   string `json:"tool"`
+	Result string `json:"result"`
+}
+
+// printToolResults writes each tool result as "<tool_name>: <result>", or as a JSON array in jsonMode
+func printToolResults(w io.Writer, calls []openai.ChatCompletionMessageToolCall, results []string, jsonMode bool) error {
+	if jsonMode {
+		out := make([]toolResultOutput, 0, len(calls))
+		for i, call := range calls {
+			out = append(out, toolResultOutput{Tool: call.Function.Name, Result: results[i]})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(out)
+	}
+	for i, call := range calls {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", call.Function.Name, results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func weatherRegistry(t *testing.T, result string) *ToolRegistry {
+	t.Helper()
+	return stubToolRegistry(t, "get_weather", func(context.Context, map[string]interface{}) (string, error) {
+		return result, nil
+	})
+}
+
+func TestOutputOnlyToolResultsSkipsFinalRequest(t *testing.T) {
+	setFlag(t, outputOnlyToolResults, true)
+	call := testToolCall("call_1", "get_weather", `{"location":"New York City"}`)
+	gateway := newTestGateway(t, testCompletion(t, "", call), testCompletion(t, "should not be requested"))
+
+	_, _, err := runConversation(context.Background(), newTestClient(gateway.URL), weatherRegistry(t, "Sunny, 25°C"), conversationOptions{Question: "Weather?"})
+	if !errors.Is(err, errStoppedAfterTools) {
+		t.Fatalf("err = %v, want errStoppedAfterTools", err)
+	}
+	if n := len(gateway.Requests()); n != 1 {
+		t.Errorf("gateway got %d requests, want only the initial one", n)
+	}
+}
+
+func TestPrintToolResults(t *testing.T) {
+	calls := testToolCallsNamed("get_weather", "get_weather")
+	results := []string{"Sunny, 25°C", "Rain"}
+
+	var text bytes.Buffer
+	if err := printToolResults(&text, calls, results, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "get_weather: Sunny, 25°C\nget_weather: Rain\n"; text.String() != want {
+		t.Errorf("text output = %q, want %q", text.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := printToolResults(&js, calls[:1], results[:1], true); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"tool":"get_weather","result":"Sunny, 25°C"}]` + "\n"; js.String() != want {
+		t.Errorf("JSON output = %q, want %q", js.String(), want)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	openai "github.com/openai/openai-go"
+)
+
+// ToolHandler executes a tool call with its decoded arguments
+type ToolHandler func(ctx context.Context, args map[string]interface{}) (string, error)
+
+// ToolFetcher calls a remote implementation of a tool at serviceURL
+type ToolFetcher func(ctx context.Context, serviceURL string, args map[string]interface{}) (string, error)
+
+// Tool is a function the model can call
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  openai.FunctionParameters
+	Handler     ToolHandler
+	// Fetch, when set, lets the tool be served from URLs such as -tool-fallback-chain
+	Fetch ToolFetcher
+}
+
+// ToolRegistry holds the tools offered to the model, in registration order
+type ToolRegistry struct {
+	// MaxArguments limits the number of schema properties per tool; 0 means unlimited
+	MaxArguments int
+	// Cache, when set, answers repeated identical calls across the session
+	Cache *SessionToolCache
+	// Verify, when set, checks each new tool result before it is given to the model
+	Verify func(ctx context.Context, result string) (verified bool, reason string, err error)
+	// ParallelLimit, when set, caps concurrent calls per tool in dispatchToolCallsConcurrently
+	ParallelLimit *PerTypeParallelLimiter
+
+	tools []*Tool
+	index map[string]*Tool
+}
+
+// NewToolRegistry returns an empty registry
+func NewToolRegistry(maxArguments int) *ToolRegistry {
+	return &ToolRegistry{MaxArguments: maxArguments, index: map[string]*Tool{}}
+}
+
+// Register adds a tool, rejecting duplicates and schemas over the argument limit
+func (r *ToolRegistry) Register(tool Tool) error {
+	if _, exists := r.index[tool.Name]; exists {
+		return fmt.Errorf("tool %q is already registered", tool.Name)
+	}
+	if err := r.checkArguments(tool); err != nil {
+		return err
+	}
+	t := &tool
+	r.tools = append(r.tools, t)
+	r.index[tool.Name] = t
+	return nil
+}
+
+// Get returns the tool registered under name
+func (r *ToolRegistry) Get(name string) (*Tool, bool) {
+	t, ok := r.index[name]
+	return t, ok
+}
+
+// Tools returns the registered tools in registration order
+func (r *ToolRegistry) Tools() []*Tool {
+	return r.tools
+}
+
+// ToParams converts the registered tools into request params
+func (r *ToolRegistry) ToParams() ([]openai.ChatCompletionToolParam, error) {
+	params := make([]openai.ChatCompletionToolParam, 0, len(r.tools))
+	for _, t := range r.tools {
+		if err := r.checkArguments(*t); err != nil {
+			return nil, err
+		}
+		params = append(params, openai.ChatCompletionToolParam{
+			Type: openai.F(openai.ChatCompletionToolTypeFunction),
+			Function: openai.F(openai.FunctionDefinitionParam{
+				Name:        openai.String(t.Name),
+				Description: openai.String(t.Description),
+				Parameters:  openai.F(t.Parameters),
+			}),
+		})
+	}
+	return params, nil
+}
+
+func (r *ToolRegistry) checkArguments(tool Tool) error {
+	if r.MaxArguments <= 0 {
+		return nil
+	}
+	if n := countSchemaProperties(tool.Parameters); n > r.MaxArguments {
+		return fmt.Errorf("tool %q has %d arguments, more than the limit of %d", tool.Name, n, r.MaxArguments)
+	}
+	return nil
+}
+
+// countSchemaProperties returns the number of keys in the schema's "properties" object
+func countSchemaProperties(params openai.FunctionParameters) int {
+	switch props := params["properties"].(type) {
+	case map[string]interface{}:
+		return len(props)
+	case map[string]map[string]interface{}:
+		return len(props)
+	case map[string]map[string]string:
+		return len(props)
+	}
+	return 0
+}
+
+// newDefaultToolRegistry registers the built-in demo tools
+func newDefaultToolRegistry(maxArguments int) (*ToolRegistry, error) {
+	registry := NewToolRegistry(maxArguments)
+	err := registry.Register(Tool{
+		Name:        "get_weather",
+		Description: "Get weather at the given location",
+		Parameters: openai.FunctionParameters{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"location": map[string]string{"type": "string"},
+			},
+			"required": []string{"location"},
+		},
+		Handler: getWeather,
+		Fetch:   fetchWeatherTool,
+	})
+	return registry, err
+}
+
+// getWeather looks up the weather from -tool-url, or simulates it when no service is configured
+func getWeather(ctx context.Context, args map[string]interface{}) (string, error) {
+	location, _ := args["location"].(string)
+	if *toolURL != "" {
+		return fetchWeather(ctx, *toolURL, location)
+	}
+	if location != "New York City" {
+		log.Printf("Expected location to be New York City but got %s", location)
+	}
+	return "Sunny, 25°C", nil
+}
+
+// fetchWeatherTool adapts fetchWeather to ToolFetcher
+func fetchWeatherTool(ctx context.Context, serviceURL string, args map[string]interface{}) (string, error) {
+	location, _ := args["location"].(string)
+	return fetchWeather(ctx, serviceURL, location)
+}
+
+// dispatchToolCall decodes the call arguments and runs the matching tool
+func dispatchToolCall(ctx context.Context, registry *ToolRegistry, toolCall openai.ChatCompletionMessageToolCall) (string, error) {
+	tool, ok := registry.Get(toolCall.Function.Name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", toolCall.Function.Name)
+	}
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return "", fmt.Errorf("unmarshalling the function arguments: %w", err)
+	}
+	if err := validateToolArgs(tool, args, ValidationMode(*toolSchemaValidationMode), *toolSchemaCoerceTypes); err != nil {
+		return "", err
+	}
+	return tool.Handler(ctx, args)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+// toolWithArguments returns a tool whose schema has n string properties
+func toolWithArguments(name string, n int) Tool {
+	props := map[string]interface{}{}
+	for i := 0; i < n; i++ {
+		props[fmt.Sprintf("arg%d", i)] = map[string]string{"type": "string"}
+	}
+	return Tool{Name: name, Parameters: openai.FunctionParameters{"type": "object", "properties": props}}
+}
+
+func TestRegisterRejectsTooManyArguments(t *testing.T) {
+	registry := NewToolRegistry(10)
+	if err := registry.Register(toolWithArguments("wide", 11)); err == nil {
+		t.Error("registering a tool with 11 arguments under a limit of 10 succeeded")
+	}
+	if err := registry.Register(toolWithArguments("ok", 10)); err != nil {
+		t.Errorf("registering a tool with 10 arguments failed: %v", err)
+	}
+	if err := NewToolRegistry(0).Register(toolWithArguments("unlimited", 50)); err != nil {
+		t.Errorf("a zero limit rejected a tool: %v", err)
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	registry := NewToolRegistry(0)
+	registry.Register(toolWithArguments("get_weather", 1))
+	if err := registry.Register(toolWithArguments("get_weather", 1)); err == nil {
+		t.Error("duplicate tool registration succeeded")
+	}
+}
+
+func TestCountSchemaProperties(t *testing.T) {
+	tests := []struct {
+		params openai.FunctionParameters
+		want   int
+	}{
+		{openai.FunctionParameters{"properties": map[string]interface{}{"a": nil, "b": nil}}, 2},
+		{openai.FunctionParameters{"properties": map[string]map[string]string{"a": {}}}, 1},
+		{openai.FunctionParameters{"type": "object"}, 0},
+	}
+	for _, tt := range tests {
+		if got := countSchemaProperties(tt.params); got != tt.want {
+			t.Errorf("countSchemaProperties(%v) = %d, want %d", tt.params, got, tt.want)
+		}
+	}
+}
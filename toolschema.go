@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	openai "github.com/openai/openai-go"
+)
+
+// ValidationMode controls how strictly tool arguments are checked against the tool schema
+type ValidationMode string
+
+const (
+	// ValidationStrict requires all required fields and rejects fields missing from the schema
+	ValidationStrict ValidationMode = "strict"
+	// ValidationLenient requires all required fields and only warns about unknown fields
+	ValidationLenient ValidationMode = "lenient"
+	// ValidationOff skips validation
+	ValidationOff ValidationMode = "off"
+)
+
+// parseValidationMode checks the -tool-schema-validation-mode value
+func parseValidationMode(value string) (ValidationMode, error) {
+	switch mode := ValidationMode(value); mode {
+	case ValidationStrict, ValidationLenient, ValidationOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown validation mode %q (want strict, lenient or off)", value)
+}
+
+// toolSchema is the part of a tool's JSON schema used for argument validation
+type toolSchema struct {
+	Properties map[string]struct {
+		Type string `json:"type"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+// decodeToolSchema normalizes the schema, whatever Go types it was declared with
+func decodeToolSchema(params openai.FunctionParameters) (toolSchema, error) {
+	var schema toolSchema
+	data, err := json.Marshal(params)
+	if err != nil {
+		return schema, err
+	}
+	err = json.Unmarshal(data, &schema)
+	return schema, err
+}
+
+// validateToolArgs checks args against the tool's schema according to mode. With
+// coerce, string values are first converted in place to the number, integer or boolean
+// the schema expects.
+func validateToolArgs(tool *Tool, args map[string]interface{}, mode ValidationMode, coerce bool) error {
+	if mode == ValidationOff && !coerce {
+		return nil
+	}
+	schema, err := decodeToolSchema(tool.Parameters)
+	if err != nil {
+		return fmt.Errorf("reading schema of %s: %w", tool.Name, err)
+	}
+	if coerce {
+		for name, prop := range schema.Properties {
+			if s, ok := args[name].(string); ok {
+				args[name] = coerceSchemaValue(s, prop.Type)
+			}
+		}
+	}
+	if mode == ValidationOff {
+		return nil
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("missing required argument %q for %s", name, tool.Name)
+		}
+	}
+	var extra []string
+	for name := range args {
+		if _, ok := schema.Properties[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	sort.Strings(extra)
+	if mode == ValidationStrict {
+		return fmt.Errorf("unexpected arguments %q for %s", extra, tool.Name)
+	}
+	log.Printf("Warning: ignoring unexpected arguments %q for %s", extra, tool.Name)
+	return nil
+}
+
+// coerceSchemaValue converts s to the JSON schema type, leaving it unchanged when it does not parse
+func coerceSchemaValue(s, schemaType string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+)
+
+// forecastTool has a required string and an optional integer argument
+var forecastTool = &Tool{
+	Name: "get_forecast",
+	Parameters: openai.FunctionParameters{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"location": map[string]string{"type": "string"},
+			"days":     map[string]string{"type": "integer"},
+		},
+		"required": []string{"location"},
+	},
+}
+
+func TestValidateToolArgsModes(t *testing.T) {
+	tests := []struct {
+		mode    ValidationMode
+		args    map[string]interface{}
+		wantErr bool
+	}{
+		{ValidationStrict, map[string]interface{}{"location": "NYC", "units": "metric"}, true},
+		{ValidationLenient, map[string]interface{}{"location": "NYC", "units": "metric"}, false},
+		{ValidationOff, map[string]interface{}{"location": "NYC", "units": "metric"}, false},
+		{ValidationStrict, map[string]interface{}{"days": 3}, true},
+		{ValidationLenient, map[string]interface{}{"days": 3}, true},
+		{ValidationOff, map[string]interface{}{"days": 3}, false},
+		{ValidationStrict, map[string]interface{}{"location": "NYC", "days": 3}, false},
+	}
+	for _, tt := range tests {
+		err := validateToolArgs(forecastTool, tt.args, tt.mode, false)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%s with %v: err = %v, want error %v", tt.mode, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateToolArgsCoercion(t *testing.T) {
+	for _, mode := range []ValidationMode{ValidationStrict, ValidationLenient, ValidationOff} {
+		args := map[string]interface{}{"location": "NYC", "days": "25"}
+		if err := validateToolArgs(forecastTool, args, mode, true); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if want := map[string]interface{}{"location": "NYC", "days": int64(25)}; !reflect.DeepEqual(args, want) {
+			t.Errorf("%s: coerced args = %#v, want %#v", mode, args, want)
+		}
+	}
+
+	args := map[string]interface{}{"location": "NYC", "days": "soon"}
+	if err := validateToolArgs(forecastTool, args, ValidationLenient, true); err != nil {
+		t.Fatal(err)
+	}
+	if args["days"] != "soon" {
+		t.Errorf("unparsable value was changed to %#v", args["days"])
+	}
+}
+
+func TestCoerceSchemaValue(t *testing.T) {
+	tests := []struct {
+		s, schemaType string
+		want          interface{}
+	}{
+		{"25", "integer", int64(25)},
+		{"2.5", "number", 2.5},
+		{"true", "boolean", true},
+		{"25", "string", "25"},
+		{"2.5", "integer", "2.5"},
+	}
+	for _, tt := range tests {
+		if got := coerceSchemaValue(tt.s, tt.schemaType); got != tt.want {
+			t.Errorf("coerceSchemaValue(%q, %s) = %#v, want %#v", tt.s, tt.schemaType, got, tt.want)
+		}
+	}
+}
+
+func TestParseValidationMode(t *testing.T) {
+	if _, err := parseValidationMode("loose"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if mode, err := parseValidationMode("strict"); err != nil || mode != ValidationStrict {
+		t.Errorf("parseValidationMode(strict) = %v, %v", mode, err)
+	}
+}
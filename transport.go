@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// RequestIDTransport sets a request ID header on every outgoing request.
+// When ID is empty a new UUID is generated per request.
+type RequestIDTransport struct {
+	Base   http.RoundTripper
+	Header string
+	ID     string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := t.ID
+	if id == "" {
+		id = newUUID()
+	}
+	log.Printf("Sending %s %s with %s=%s", req.Method, req.URL.Path, t.Header, id)
+
+	req = req.Clone(req.Context())
+	req.Header.Set(t.Header, id)
+	return t.base().RoundTrip(req)
+}
+
+func (t *RequestIDTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// headerRecorder returns a server that records the value of header on each request
+func headerRecorder(t *testing.T, header string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get(header))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func getTwice(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestRequestIDFromEnvironment(t *testing.T) {
+	setFlag(t, gatewayRequestIDEnv, "REQUEST_ID")
+	setFlag(t, gatewayRequestIDHeader, "X-Trace-ID")
+	t.Setenv("REQUEST_ID", "upstream-trace-123")
+	srv, seen := headerRecorder(t, "X-Trace-ID")
+
+	client, err := buildHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getTwice(t, client, srv.URL)
+	for i, id := range *seen {
+		if id != "upstream-trace-123" {
+			t.Errorf("request %d sent ID %q, want the REQUEST_ID value", i, id)
+		}
+	}
+}
+
+func TestRequestIDGeneratedWhenUnset(t *testing.T) {
+	setFlag(t, gatewayRequestIDEnv, "REQUEST_ID")
+	t.Setenv("REQUEST_ID", "")
+	srv, seen := headerRecorder(t, "X-Request-ID")
+
+	client, err := buildHTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getTwice(t, client, srv.URL)
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for i, id := range *seen {
+		if !uuid.MatchString(id) {
+			t.Errorf("request %d sent %q, want a v4 UUID", i, id)
+		}
+	}
+	if (*seen)[0] == (*seen)[1] {
+		t.Error("both requests reused the same generated ID")
+	}
+}
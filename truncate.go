@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+)
+
+// truncationMarker is appended to responses that were shortened
+const truncationMarker = "[truncated]"
+
+const summaryPromptPrefix = "Summarize the following response concisely, keeping every fact needed to answer the original question: "
+
+// TruncationConfig holds the limits used by the truncation strategies; zero limits disable them
+type TruncationConfig struct {
+	MaxChars   int
+	Sentences  int
+	Paragraphs int
+	// Summarize shortens text for the summary strategy
+	Summarize func(text string) (string, error)
+}
+
+// TruncationStrategy shortens a response according to cfg
+type TruncationStrategy interface {
+	Truncate(text string, cfg TruncationConfig) string
+}
+
+// truncationStrategies maps -response-truncation-strategy values to their implementation
+var truncationStrategies = map[string]TruncationStrategy{
+	"chars":      charsTruncation{},
+	"sentences":  sentencesTruncation{},
+	"paragraphs": paragraphsTruncation{},
+	"summary":    summaryTruncation{},
+}
+
+// truncation is the configuration built from the -response-truncation-strategy flags
+var truncation TruncationConfig
+
+// charsTruncation keeps the first MaxChars characters
+type charsTruncation struct{}
+
+func (charsTruncation) Truncate(text string, cfg TruncationConfig) string {
+	runes := []rune(text)
+	if cfg.MaxChars <= 0 || len(runes) <= cfg.MaxChars {
+		return text
+	}
+	return strings.TrimRight(string(runes[:cfg.MaxChars]), " \t\n") + " " + truncationMarker
+}
+
+// sentencesTruncation keeps the first Sentences sentences
+type sentencesTruncation struct{}
+
+func (sentencesTruncation) Truncate(text string, cfg TruncationConfig) string {
+	sentences := splitSentences(text)
+	if cfg.Sentences <= 0 || len(sentences) <= cfg.Sentences {
+		return text
+	}
+	return strings.Join(sentences[:cfg.Sentences], " ") + " " + truncationMarker
+}
+
+// paragraphBreak separates paragraphs: a line break followed by a blank line
+var paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+// paragraphsTruncation keeps the first Paragraphs paragraphs
+type paragraphsTruncation struct{}
+
+func (paragraphsTruncation) Truncate(text string, cfg TruncationConfig) string {
+	paragraphs := paragraphBreak.Split(strings.TrimSpace(text), -1)
+	if cfg.Paragraphs <= 0 || len(paragraphs) <= cfg.Paragraphs {
+		return text
+	}
+	return strings.Join(paragraphs[:cfg.Paragraphs], "\n\n") + "\n\n" + truncationMarker
+}
+
+// summaryTruncation replaces responses longer than MaxChars with a model-written summary
+type summaryTruncation struct{}
+
+func (summaryTruncation) Truncate(text string, cfg TruncationConfig) string {
+	if cfg.MaxChars <= 0 || len([]rune(text)) <= cfg.MaxChars || cfg.Summarize == nil {
+		return text
+	}
+	summary, err := cfg.Summarize(text)
+	if err != nil {
+		log.Printf("Warning: summarizing the response failed, truncating instead: %v", err)
+		return charsTruncation{}.Truncate(text, cfg)
+	}
+	return summary
+}
+
+// splitSentences splits text after each '.', '!' or '?' that ends a sentence, as firstSentence does
+func splitSentences(text string) []string {
+	var sentences []string
+	for text = strings.TrimSpace(text); text != ""; {
+		sentence := firstSentence(text)
+		sentences = append(sentences, sentence)
+		text = strings.TrimSpace(text[len(sentence):])
+	}
+	return sentences
+}
+
+// summarizeText asks model for a shorter version of text
+func summarizeText(ctx context.Context, client *openai.Client, model, text string) (string, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(summaryPromptPrefix + text),
+		}),
+		Model: openai.F(model),
+	}
+	resp, err := sendRequest(ctx, client, params)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("summary response has no choices")
+	}
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if summary == "" {
+		return "", errors.New("summary response is empty")
+	}
+	return summary, nil
+}
+
+// truncationStrategy returns the strategy named by -response-truncation-strategy
+func truncationStrategy(name string) (TruncationStrategy, error) {
+	strategy, ok := truncationStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown truncation strategy %q (want chars, sentences, paragraphs or summary)", name)
+	}
+	return strategy, nil
+}
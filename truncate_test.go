@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSentencesTruncation(t *testing.T) {
+	var sentences []string
+	for i := 1; i <= 10; i++ {
+		sentences = append(sentences, fmt.Sprintf("This is sentence %d.", i))
+	}
+	text := strings.Join(sentences, " ")
+	got := sentencesTruncation{}.Truncate(text, TruncationConfig{Sentences: 3})
+	want := "This is sentence 1. This is sentence 2. This is sentence 3. " + truncationMarker
+	if got != want {
+		t.Errorf("Truncate = %q, want %q", got, want)
+	}
+	if kept := splitSentences(strings.TrimSuffix(got, truncationMarker)); len(kept) != 3 {
+		t.Errorf("kept %d sentences, want 3", len(kept))
+	}
+	if got := (sentencesTruncation{}).Truncate(text, TruncationConfig{Sentences: 10}); got != text {
+		t.Errorf("text within the limit was changed to %q", got)
+	}
+}
+
+func TestTruncationStrategies(t *testing.T) {
+	summarize := func(string) (string, error) { return "Short summary.", nil }
+	failing := func(string) (string, error) { return "", errors.New("gateway down") }
+	tests := []struct {
+		strategy string
+		text     string
+		cfg      TruncationConfig
+		want     string
+	}{
+		{"chars", "Sunny and warm today", TruncationConfig{MaxChars: 10}, "Sunny and " + truncationMarker},
+		{"chars", "Sunny", TruncationConfig{MaxChars: 10}, "Sunny"},
+		{"paragraphs", "One.\n\nTwo.\n \nThree.", TruncationConfig{Paragraphs: 2}, "One.\n\nTwo.\n\n" + truncationMarker},
+		{"summary", "A response that is far too long", TruncationConfig{MaxChars: 10, Summarize: summarize}, "Short summary."},
+		{"summary", "A response that is far too long", TruncationConfig{MaxChars: 10, Summarize: failing}, "A response " + truncationMarker},
+	}
+	for _, tt := range tests {
+		strategy, err := truncationStrategy(tt.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strategy.Truncate(tt.text, tt.cfg); got != tt.want {
+			t.Errorf("%s: Truncate(%q) = %q, want %q", tt.strategy, tt.text, got, tt.want)
+		}
+	}
+	if _, err := truncationStrategy("words"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	openai "github.com/openai/openai-go"
+)
+
+const verifyPromptPrefix = "Is the following tool result plausible and non-contradictory? Answer YES or NO with a brief reason: "
+
+// unverifiedResultPrefix marks tool results the verifier rejected
+const unverifiedResultPrefix = "Unverified tool result: "
+
+// verifyToolResult asks model whether result is plausible. The reason is whatever the
+// verifier said after its YES or NO; any other answer is an error.
+func verifyToolResult(ctx context.Context, client *openai.Client, model, result string) (verified bool, reason string, err error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(verifyPromptPrefix + result),
+		}),
+		Model:     openai.F(model),
+		MaxTokens: openai.Int(100),
+	}
+	resp, err := sendRequest(ctx, client, params)
+	if err != nil {
+		return false, "", err
+	}
+	if len(resp.Choices) == 0 {
+		return false, "", errors.New("verification response has no choices")
+	}
+
+	answer := strings.TrimSpace(resp.Choices[0].Message.Content)
+	// The first word decides, so replies like "NOTE: ..." or "NONE" are not read as NO
+	end := strings.IndexFunc(answer, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		end = len(answer)
+	}
+	switch word := answer[:end]; {
+	case strings.EqualFold(word, "YES"):
+		verified = true
+	case strings.EqualFold(word, "NO"):
+		verified = false
+	default:
+		return false, "", fmt.Errorf("unexpected verification answer %q", answer)
+	}
+	return verified, strings.TrimSpace(strings.TrimLeft(answer[end:], ".,:;-! ")), nil
+}
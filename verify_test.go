@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHandleToolCallAnnotatesUnverifiedResult(t *testing.T) {
+	gateway := newTestGateway(t, testCompletion(t, "NO. 80°C is implausible for New York in winter."))
+	client := newTestClient(gateway.URL)
+	registry := stubToolRegistry(t, "get_weather", func(context.Context, map[string]interface{}) (string, error) {
+		return "Sunny, 80°C", nil
+	})
+	registry.Verify = func(ctx context.Context, result string) (bool, string, error) {
+		return verifyToolResult(ctx, client, "verifier-model", result)
+	}
+
+	msg := handleToolCall(context.Background(), registry, testToolCall("call_1", "get_weather", "{}"))
+	if got, want := messageText(msg), unverifiedResultPrefix+"Sunny, 80°C"; got != want {
+		t.Errorf("tool message = %q, want %q", got, want)
+	}
+	requests := gateway.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("verifier received %d requests, want 1", len(requests))
+	}
+	if got := decodeRequestMessages(t, requests[0]); len(got) != 1 || got[0].Content != verifyPromptPrefix+"Sunny, 80°C" {
+		t.Errorf("verification prompt = %+v", got)
+	}
+}
+
+func TestVerifyToolResultAnswers(t *testing.T) {
+	tests := []struct {
+		answer       string
+		wantVerified bool
+		wantReason   string
+		wantErr      bool
+	}{
+		{"YES, the forecast is plausible.", true, "the forecast is plausible.", false},
+		{"no - temperatures contradict each other", false, "temperatures contradict each other", false},
+		{"NO", false, "", false},
+		{"Yes: 25°C is normal for July", true, "25°C is normal for July", false},
+		{"NOTE: the result looks plausible, YES", false, "", true},
+		{"NONE of the values contradict", false, "", true},
+		{"Maybe", false, "", true},
+	}
+	for _, tt := range tests {
+		gateway := newTestGateway(t, testCompletion(t, tt.answer))
+		verified, reason, err := verifyToolResult(context.Background(), newTestClient(gateway.URL), "verifier-model", "Sunny")
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%q: err = %v, want error %v", tt.answer, err, tt.wantErr)
+			continue
+		}
+		if verified != tt.wantVerified || reason != tt.wantReason {
+			t.Errorf("%q: got %v %q, want %v %q", tt.answer, verified, reason, tt.wantVerified, tt.wantReason)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+	"golang.org/x/net/websocket"
+)
+
+// websocketMaxReconnects is how many times a dropped WebSocket stream is retried
+const websocketMaxReconnects = 3
+
+// websocketDoneMessage optionally marks the end of a stream, as in SSE
+const websocketDoneMessage = "[DONE]"
+
+// WebSocketGatewayClient streams completions from the gateway's WebSocket endpoint.
+// The request params are sent as one JSON message and every message received back is
+// a chat completion chunk; the stream ends when the server closes the connection.
+type WebSocketGatewayClient struct {
+	URL    string
+	Origin string
+	APIKey string
+	// Token, when set, is sent as the Bearer token instead of APIKey
+	Token string
+	// RequestIDHeader and RequestID set a request ID on each handshake, as
+	// RequestIDTransport does for HTTP requests; an empty RequestID generates one per connection
+	RequestIDHeader string
+	RequestID       string
+	// TLSConfig is used for wss:// connections, nil for the system defaults
+	TLSConfig *tls.Config
+	// MaxReconnects bounds how often a failed connection is retried
+	MaxReconnects int
+}
+
+// newWebSocketGatewayClient builds a client for ws://<gateway>/v1/chat from the gateway
+// HTTP URL, authenticating, tagging and verifying connections like the HTTP client does
+func newWebSocketGatewayClient(gatewayURL string) (*WebSocketGatewayClient, error) {
+	base := strings.TrimSuffix(gatewayURL, "/")
+	origin := base
+	switch {
+	case strings.HasPrefix(base, "https://"):
+		base = "wss://" + strings.TrimPrefix(base, "https://")
+	case strings.HasPrefix(base, "http://"):
+		base = "ws://" + strings.TrimPrefix(base, "http://")
+	}
+	tlsConfig, err := gatewayTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &WebSocketGatewayClient{
+		URL:             base + "/v1/chat",
+		Origin:          origin,
+		APIKey:          os.Getenv("OPENAI_API_KEY"),
+		Token:           serviceAccountToken,
+		RequestIDHeader: *gatewayRequestIDHeader,
+		RequestID:       gatewayRequestIDValue(),
+		TLSConfig:       tlsConfig,
+		MaxReconnects:   websocketMaxReconnects,
+	}, nil
+}
+
+// Stream sends params and accumulates the streamed chunks, calling onDelta with each
+// content delta. A connection that fails before any delta was delivered restarts the
+// request up to MaxReconnects times. Once a delta has been delivered the request is not
+// retried, since a fresh response would repeat or contradict the text already shown.
+func (c *WebSocketGatewayClient) Stream(ctx context.Context, params openai.ChatCompletionNewParams, onDelta func(string)) (*openai.ChatCompletion, error) {
+	delivered := false
+	deliver := func(delta string) {
+		delivered = true
+		if onDelta != nil {
+			onDelta(delta)
+		}
+	}
+	var err error
+	for attempt := 0; attempt <= c.MaxReconnects; attempt++ {
+		if attempt > 0 {
+			log.Printf("Warning: WebSocket stream from %s failed (%v), reconnecting (%d/%d)", c.URL, err, attempt, c.MaxReconnects)
+		}
+		var resp *openai.ChatCompletion
+		if resp, err = c.streamOnce(ctx, params, deliver); err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || delivered {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// streamOnce runs a single connection's worth of the stream
+func (c *WebSocketGatewayClient) streamOnce(ctx context.Context, params openai.ChatCompletionNewParams, onDelta func(string)) (*openai.ChatCompletion, error) {
+	config, err := websocket.NewConfig(c.URL, c.Origin)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c.Token != "":
+		config.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.APIKey != "":
+		config.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	if c.RequestIDHeader != "" {
+		id := c.RequestID
+		if id == "" {
+			id = newUUID()
+		}
+		log.Printf("Connecting to %s with %s=%s", c.URL, c.RequestIDHeader, id)
+		config.Header.Set(c.RequestIDHeader, id)
+	}
+	config.TlsConfig = c.TLSConfig
+	ctx, cancel := withGatewayTimeout(ctx, gatewayCallTimeoutMs())
+	defer cancel()
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// Unblock Receive when the context ends
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := websocket.Message.Send(conn, string(body)); err != nil {
+		return nil, err
+	}
+
+	var acc openai.ChatCompletionAccumulator
+	for {
+		var data string
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if strings.TrimSpace(data) == websocketDoneMessage {
+			break
+		}
+		var chunk openai.ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("decoding WebSocket chunk: %w", err)
+		}
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if len(acc.Choices) == 0 {
+		return nil, errors.New("WebSocket stream closed without any choices")
+	}
+	acc.Choices[0].Message.Role = openai.ChatCompletionMessageRoleAssistant
+	return &acc.ChatCompletion, nil
+}
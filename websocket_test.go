@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"golang.org/x/net/websocket"
+)
+
+// websocketChunk is a streamed chat completion chunk carrying one content delta
+func websocketChunk(content string) string {
+	return fmt.Sprintf(`{"id":"chatcmpl-ws","object":"chat.completion.chunk","created":1700000000,"model":"test-model","choices":[{"index":0,"delta":{"content":%q}}]}`, content)
+}
+
+// fakeWebSocketGateway runs script for each connection, numbered from 0, after reading
+// the request message, and records the handshake headers
+type fakeWebSocketGateway struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func newFakeWebSocketGateway(t *testing.T, tls bool, script func(n int, conn *websocket.Conn)) *fakeWebSocketGateway {
+	t.Helper()
+	g := &fakeWebSocketGateway{}
+	handler := websocket.Handler(func(conn *websocket.Conn) {
+		g.mu.Lock()
+		n := len(g.headers)
+		g.headers = append(g.headers, conn.Request().Header.Clone())
+		g.mu.Unlock()
+		var request string
+		if err := websocket.Message.Receive(conn, &request); err != nil {
+			return
+		}
+		script(n, conn)
+	})
+	if tls {
+		g.Server = httptest.NewTLSServer(handler)
+	} else {
+		g.Server = httptest.NewServer(handler)
+	}
+	t.Cleanup(g.Close)
+	return g
+}
+
+// Connections returns the handshake headers of each connection so far
+func (g *fakeWebSocketGateway) Connections() []http.Header {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]http.Header(nil), g.headers...)
+}
+
+// sendChunks sends one chunk per delta
+func sendChunks(conn *websocket.Conn, deltas ...string) {
+	for _, delta := range deltas {
+		websocket.Message.Send(conn, websocketChunk(delta))
+	}
+}
+
+func websocketTestParams() openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("Weather?")}),
+		Model:    openai.F("test-model"),
+	}
+}
+
+func TestWebSocketStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      func(n int, conn *websocket.Conn)
+		wantConns   int
+		wantErr     bool
+		wantDeltas  []string
+		wantContent string
+	}{
+		{
+			name:        "three deltas then close",
+			script:      func(n int, conn *websocket.Conn) { sendChunks(conn, "It is", " sunny", " today.") },
+			wantConns:   1,
+			wantDeltas:  []string{"It is", " sunny", " today."},
+			wantContent: "It is sunny today.",
+		},
+		{
+			name: "reconnects after a connection closed before any delta",
+			script: func(n int, conn *websocket.Conn) {
+				if n == 0 {
+					return
+				}
+				sendChunks(conn, "It is", " sunny", " today.")
+			},
+			wantConns:   2,
+			wantDeltas:  []string{"It is", " sunny", " today."},
+			wantContent: "It is sunny today.",
+		},
+		{
+			name: "no reconnect once a delta was delivered",
+			script: func(n int, conn *websocket.Conn) {
+				sendChunks(conn, "It is")
+				websocket.Message.Send(conn, "not json")
+			},
+			wantConns:  1,
+			wantErr:    true,
+			wantDeltas: []string{"It is"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := newFakeWebSocketGateway(t, false, tt.script)
+			client, err := newWebSocketGatewayClient(gateway.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var deltas []string
+			resp, err := client.Stream(context.Background(), websocketTestParams(), func(delta string) {
+				deltas = append(deltas, delta)
+			})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Stream error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := len(gateway.Connections()); got != tt.wantConns {
+				t.Errorf("gateway saw %d connections, want %d", got, tt.wantConns)
+			}
+			if strings.Join(deltas, "|") != strings.Join(tt.wantDeltas, "|") {
+				t.Errorf("deltas = %q, want %q", deltas, tt.wantDeltas)
+			}
+			if err == nil && resp.Choices[0].Message.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", resp.Choices[0].Message.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestWebSocketHandshakeMatchesHTTPClient(t *testing.T) {
+	gateway := newFakeWebSocketGateway(t, true, func(n int, conn *websocket.Conn) { sendChunks(conn, "Sunny") })
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: gateway.Certificate().Raw})
+	if err := os.WriteFile(bundle, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, gatewayCABundle, bundle)
+	setFlag(t, gatewayCABundleOverride, true)
+	setFlag(t, gatewayRequestID, "req-123")
+	setFlag(t, &serviceAccountToken, "sa-token")
+
+	client, err := newWebSocketGatewayClient(gateway.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(client.URL, "wss://") {
+		t.Fatalf("URL = %s, want wss://", client.URL)
+	}
+	if _, err := client.Stream(context.Background(), websocketTestParams(), nil); err != nil {
+		t.Fatal(err)
+	}
+	header := gateway.Connections()[0]
+	if got := header.Get("Authorization"); got != "Bearer sa-token" {
+		t.Errorf("Authorization = %q, want the service account token", got)
+	}
+	if got := header.Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("X-Request-ID = %q, want req-123", got)
+	}
+
+	// Without the bundle the test server's certificate is not trusted
+	setFlag(t, gatewayCABundle, "")
+	client, err = newWebSocketGatewayClient(gateway.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.MaxReconnects = 0
+	if _, err := client.Stream(context.Background(), websocketTestParams(), nil); err == nil {
+		t.Error("connected to an untrusted server without -gateway-ca-bundle")
+	}
+}